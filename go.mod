@@ -7,7 +7,9 @@ require (
 	github.com/gin-contrib/cors v1.3.1
 	github.com/gin-gonic/gin v1.6.3
 	github.com/google/go-cmp v0.5.0
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/twinj/uuid v1.0.0
+	golang.org/x/crypto v0.0.0-20200220183623-bac4c82f6975
 	k8s.io/api v0.18.5
 	k8s.io/apimachinery v0.18.5
 	k8s.io/client-go v0.18.5
@@ -0,0 +1,65 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListUsers - List all registered users
+func AdminListUsers(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminListUsers(c)
+}
+
+// AdminListGameServers - List the game servers of all users
+func AdminListGameServers(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminListGameServers(c)
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func AdminListOrphanedGameServers(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminListOrphanedGameServers(c)
+}
+
+// AdminGetUser - Get a registered user
+func AdminGetUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminGetUser(c)
+}
+
+// AdminSetUserRole - Change the role of a user
+func AdminSetUserRole(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminSetUserRole(c)
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func AdminDisableUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminDisableUser(c)
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func AdminEnableUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminEnableUser(c)
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func AdminResetUserPassword(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminResetUserPassword(c)
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func AdminDeleteUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminDeleteUser(c)
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func AdminUnlockUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().AdminUnlockUser(c)
+}
@@ -0,0 +1,20 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func ListAuditEvents(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListAuditEvents(c)
+}
@@ -24,6 +24,46 @@ func AuthLogoutDelete(c *gin.Context) {
 	NewHttpRequestProcessingChain().Logout(c)
 }
 
+// AuthRefreshPost - Exchange a refresh token for a new pair of tokens
+func AuthRefreshPost(c *gin.Context) {
+	NewHttpRequestProcessingChain().Refresh(c)
+}
+
+// Auth2faPost - Exchange a two-factor challenge token and code for a JWT with the user object
+func Auth2faPost(c *gin.Context) {
+	NewHttpRequestProcessingChain().VerifyTwoFactor(c)
+}
+
+// AuthJwksGet - Get the public keys used to sign the JWTs
+func AuthJwksGet(c *gin.Context) {
+	NewHttpRequestProcessingChain().Jwks(c)
+}
+
+// AuthVerifyGet - Verify the email address of a new user and return a JWT with the user object
+func AuthVerifyGet(c *gin.Context) {
+	NewHttpRequestProcessingChain().Verify(c)
+}
+
+// AuthForgotPost - Send a link to reset the password to the user
+func AuthForgotPost(c *gin.Context) {
+	NewHttpRequestProcessingChain().ForgotPassword(c)
+}
+
+// AuthResetPost - Set a new password using the emailed reset token
+func AuthResetPost(c *gin.Context) {
+	NewHttpRequestProcessingChain().ResetPassword(c)
+}
+
+// AuthOidcLoginGet - Redirect to the OIDC identity provider to log in
+func AuthOidcLoginGet(c *gin.Context) {
+	NewHttpRequestProcessingChain().OidcLogin(c)
+}
+
+// AuthOidcCallbackGet - Finish the login at the OIDC identity provider and return a JWT with the user object
+func AuthOidcCallbackGet(c *gin.Context) {
+	NewHttpRequestProcessingChain().OidcCallback(c)
+}
+
 // AuthRegisterPost - Register a user and return a JWT with the user object
 func AuthRegisterPost(c *gin.Context) {
 	NewHttpRequestProcessingChain().Register(c)
@@ -29,7 +29,7 @@ func DeployContainer(c *gin.Context) {
 	NewHttpRequestProcessingChain().DeployContainer(c)
 }
 
-// GetStatus - Query status of all deployments
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
 func GetStatus(c *gin.Context) {
 	NewHttpRequestProcessingChain().GetStatus(c)
 }
@@ -53,3 +53,18 @@ func StartContainer(c *gin.Context) {
 func StopContainer(c *gin.Context) {
 	NewHttpRequestProcessingChain().StopContainer(c)
 }
+
+// ListGameServerMembers - List the users a game server is shared with
+func ListGameServerMembers(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListGameServerMembers(c)
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func SetGameServerMember(c *gin.Context) {
+	NewHttpRequestProcessingChain().SetGameServerMember(c)
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func RemoveGameServerMember(c *gin.Context) {
+	NewHttpRequestProcessingChain().RemoveGameServerMember(c)
+}
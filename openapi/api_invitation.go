@@ -0,0 +1,30 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func ListInvitations(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListInvitations(c)
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func CreateInvitation(c *gin.Context) {
+	NewHttpRequestProcessingChain().CreateInvitation(c)
+}
+
+// DeleteInvitation - Delete an invitation code
+func DeleteInvitation(c *gin.Context) {
+	NewHttpRequestProcessingChain().DeleteInvitation(c)
+}
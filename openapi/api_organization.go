@@ -0,0 +1,45 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ListOrganizations - List the organizations of the user
+func ListOrganizations(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListOrganizations(c)
+}
+
+// CreateOrganization - Create an organization owned by the user
+func CreateOrganization(c *gin.Context) {
+	NewHttpRequestProcessingChain().CreateOrganization(c)
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func DeleteOrganization(c *gin.Context) {
+	NewHttpRequestProcessingChain().DeleteOrganization(c)
+}
+
+// ListOrganizationMembers - List the members of an organization
+func ListOrganizationMembers(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListOrganizationMembers(c)
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func SetOrganizationMember(c *gin.Context) {
+	NewHttpRequestProcessingChain().SetOrganizationMember(c)
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func RemoveOrganizationMember(c *gin.Context) {
+	NewHttpRequestProcessingChain().RemoveOrganizationMember(c)
+}
@@ -18,3 +18,48 @@ import (
 func UpdateUserProfile(c *gin.Context) {
 	NewHttpRequestProcessingChain().UpdateUserProfile(c)
 }
+
+// GetUserProfile - Get the profile of the authenticated user
+func GetUserProfile(c *gin.Context) {
+	NewHttpRequestProcessingChain().GetUserProfile(c)
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func EnrollTwoFactor(c *gin.Context) {
+	NewHttpRequestProcessingChain().EnrollTwoFactor(c)
+}
+
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func ConfirmTwoFactor(c *gin.Context) {
+	NewHttpRequestProcessingChain().ConfirmTwoFactor(c)
+}
+
+// DisableTwoFactor - Disable two-factor authentication
+func DisableTwoFactor(c *gin.Context) {
+	NewHttpRequestProcessingChain().DisableTwoFactor(c)
+}
+
+// DeleteUser - Delete the account of the user with all game servers
+func DeleteUser(c *gin.Context) {
+	NewHttpRequestProcessingChain().DeleteUser(c)
+}
+
+// GetAccountDeletion - Query the progress of an account deletion
+func GetAccountDeletion(c *gin.Context) {
+	NewHttpRequestProcessingChain().GetAccountDeletion(c)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func ListApiTokens(c *gin.Context) {
+	NewHttpRequestProcessingChain().ListApiTokens(c)
+}
+
+// CreateApiToken - Create a personal access token for scripts and automation
+func CreateApiToken(c *gin.Context) {
+	NewHttpRequestProcessingChain().CreateApiToken(c)
+}
+
+// RevokeApiToken - Revoke a personal access token
+func RevokeApiToken(c *gin.Context) {
+	NewHttpRequestProcessingChain().RevokeApiToken(c)
+}
@@ -0,0 +1,321 @@
+package openapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	auditConfigMap = "gamebase-audit-log"
+
+	auditOutcomeSuccess = "success"
+	auditOutcomeFailure = "failure"
+
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	// events kept by the kubernetes sink, ConfigMaps are limited to 1 MiB
+	defaultAuditRetention = 1000
+)
+
+// set up on creation of the httpRequestAuditor
+var auditLog auditSink
+
+// Filter for the audit events, empty fields match all events
+type auditQuery struct {
+	Actor  string
+	Action string
+	Target string
+	Offset int
+	Limit  int
+}
+
+func (q auditQuery) matches(event AuditEvent) bool {
+	return (q.Actor == "" || event.Actor == q.Actor) &&
+		(q.Action == "" || event.Action == q.Action) &&
+		(q.Target == "" || event.Target == q.Target)
+}
+
+// Persists the audit events and answers queries on them
+type auditSink interface {
+	Record(ctx context.Context, event AuditEvent) error
+	// The matching events newest first and the total number of matching events
+	Query(ctx context.Context, query auditQuery) ([]AuditEvent, int, error)
+}
+
+// Select the sink based on the AUDIT_SINK environment variable
+func newAuditSink(k kubernetesClient) auditSink {
+	switch sink := os.Getenv("AUDIT_SINK"); sink {
+	case "", "file":
+		path := os.Getenv("AUDIT_LOG_FILE")
+		if path == "" {
+			path = "audit.log"
+		}
+		return &fileAuditSink{path: path}
+	case "kubernetes":
+		retention := defaultAuditRetention
+		if value := os.Getenv("AUDIT_LOG_RETENTION"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				panic("Invalid AUDIT_LOG_RETENTION " + value)
+			}
+			retention = parsed
+		}
+		return &kubernetesAuditSink{k: k, retention: retention}
+	default:
+		panic("Unknown AUDIT_SINK " + sink)
+	}
+}
+
+// Select the requested page of the events, which are expected oldest first
+func pageAuditEvents(events []AuditEvent, query auditQuery) ([]AuditEvent, int) {
+	matching := []AuditEvent{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if query.matches(events[i]) {
+			matching = append(matching, events[i])
+		}
+	}
+	page := []AuditEvent{}
+	for i := query.Offset; i < len(matching) && i < query.Offset+query.Limit; i++ {
+		page = append(page, matching[i])
+	}
+	return page, len(matching)
+}
+
+// Appends the events as JSON lines to a file. Queries read the whole file.
+type fileAuditSink struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func (s *fileAuditSink) Record(ctx context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *fileAuditSink) Query(ctx context.Context, query auditQuery) ([]AuditEvent, int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return []AuditEvent{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	events := []AuditEvent{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// a partially written line, e.g. after a crash
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	page, total := pageAuditEvents(events, query)
+	return page, total, nil
+}
+
+// Keeps the newest events in a ConfigMap so they are shared between replicas.
+// The keys are the zero padded time of the event in nanoseconds followed by the event id.
+type kubernetesAuditSink struct {
+	k         kubernetesClient
+	retention int
+}
+
+func (s *kubernetesAuditSink) Record(ctx context.Context, event AuditEvent) error {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%020d.%s", event.Time.UnixNano(), event.Id)
+
+	configMaps := s.k.Client.CoreV1().ConfigMaps(defaultNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, auditConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap, err = configMaps.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: auditConfigMap},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = string(encoded)
+		keys := sortedKeys(configMap.Data)
+		for len(keys) > s.retention {
+			delete(configMap.Data, keys[0])
+			keys = keys[1:]
+		}
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (s *kubernetesAuditSink) Query(ctx context.Context, query auditQuery) ([]AuditEvent, int, error) {
+	configMap, err := s.k.Client.CoreV1().ConfigMaps(defaultNamespace).Get(ctx, auditConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []AuditEvent{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events := []AuditEvent{}
+	for _, key := range sortedKeys(configMap.Data) {
+		var event AuditEvent
+		if err := json.Unmarshal([]byte(configMap.Data[key]), &event); err != nil {
+			fmt.Println("Skipping invalid audit event " + key + ": " + err.Error())
+			continue
+		}
+		events = append(events, event)
+	}
+
+	page, total := pageAuditEvents(events, query)
+	return page, total, nil
+}
+
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Fields containing credentials are never written to the audit log
+func isSensitiveAuditField(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range []string{"password", "secret", "token", "code", "hash"} {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert the value to its JSON representation with empty and sensitive fields removed
+func auditValue(value interface{}) interface{} {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil
+	}
+	return redactAuditValue(decoded)
+}
+
+func redactAuditValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, field := range typed {
+			if field = redactAuditValue(field); field == nil {
+				continue
+			}
+			if isSensitiveAuditField(key) {
+				result[key] = "<redacted>"
+			} else {
+				result[key] = field
+			}
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	case []interface{}:
+		if len(typed) == 0 {
+			return nil
+		}
+		result := make([]interface{}, len(typed))
+		for i, item := range typed {
+			result[i] = redactAuditValue(item)
+		}
+		return result
+	case string:
+		if typed == "" {
+			return nil
+		}
+		return typed
+	default:
+		return typed
+	}
+}
+
+// Compare the JSON representations of both values and return the changed fields by path
+func auditChanges(before interface{}, after interface{}) map[string]AuditChange {
+	old := map[string]interface{}{}
+	flattenAuditValue("", auditValue(before), old)
+	updated := map[string]interface{}{}
+	flattenAuditValue("", auditValue(after), updated)
+
+	changes := map[string]AuditChange{}
+	for path, value := range old {
+		if newValue, exists := updated[path]; !exists || fmt.Sprint(newValue) != fmt.Sprint(value) {
+			changes[path] = AuditChange{Old: value, New: newValue}
+		}
+	}
+	for path, value := range updated {
+		if _, exists := old[path]; !exists {
+			changes[path] = AuditChange{New: value}
+		}
+	}
+	return changes
+}
+
+func flattenAuditValue(prefix string, value interface{}, result map[string]interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenAuditValue(path, field, result)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			flattenAuditValue(prefix+"["+strconv.Itoa(i)+"]", item, result)
+		}
+	case nil:
+	default:
+		result[prefix] = typed
+	}
+}
@@ -0,0 +1,193 @@
+package openapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	uuidGen "github.com/twinj/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	apiTokenType = "api"
+	// personal access tokens have the format gbpat.<user uuid>.<token id>.<secret>,
+	// the uuid is kept when the user changes the email address
+	apiTokenPrefix      = "gbpat."
+	maxApiTokensPerUser = 50
+)
+
+// Scopes of personal access tokens. Endpoints without a scope do not accept personal access tokens.
+const (
+	scopeGameServerRead    = "gs:read"    // list templates and query the status of game servers
+	scopeGameServerControl = "gs:control" // start, stop and restart game servers
+	scopeGameServerWrite   = "gs:write"   // deploy, configure and delete game servers
+	scopeProfileRead       = "user:read"  // read the profile of the user
+)
+
+var apiTokenScopes = []string{scopeGameServerRead, scopeGameServerControl, scopeGameServerWrite, scopeProfileRead}
+
+var (
+	errApiTokenNotFound = errors.New("token does not exist")
+	errApiTokenLimit    = errors.New("too many personal access tokens")
+	errInvalidApiToken  = errors.New("invalid token")
+)
+
+func isValidApiTokenScope(scope string) bool {
+	for _, valid := range apiTokenScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// A personal access token of a user. Only the hash of the secret is stored.
+type apiToken struct {
+	Id      string    `json:"id"`
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash"`
+	Scopes  []string  `json:"scopes"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"` // zero if the token does not expire
+}
+
+func (token apiToken) isExpired(now time.Time) bool {
+	return !token.Expires.IsZero() && now.After(token.Expires)
+}
+
+// set up on creation of the httpRequestAuthenticator
+var apiTokens *apiTokenStore
+
+// Manages the personal access tokens, which are kept with the user
+type apiTokenStore struct {
+	users UserStore
+}
+
+func newApiTokenStore(users UserStore) *apiTokenStore {
+	return &apiTokenStore{users: users}
+}
+
+func isApiToken(s string) bool {
+	return strings.HasPrefix(s, apiTokenPrefix)
+}
+
+// Create a new token for the user. The returned secret is not stored and cannot be shown again.
+func (s *apiTokenStore) Create(ctx context.Context, user *GamebaseUser, name string, scopes []string, expires time.Time) (string, apiToken, error) {
+	secret, err := randomUrlToken()
+	if err != nil {
+		return "", apiToken{}, err
+	}
+
+	token := apiToken{
+		Id:      uuidGen.NewV4().String(),
+		Name:    name,
+		Hash:    hashApiTokenSecret(secret),
+		Scopes:  scopes,
+		Created: time.Now().UTC(),
+		Expires: expires,
+	}
+	err = s.users.UpdateUser(ctx, user.Email, func(current *GamebaseUser) error {
+		if len(current.ApiTokens) >= maxApiTokensPerUser {
+			return errApiTokenLimit
+		}
+		current.ApiTokens = append(append([]apiToken{}, current.ApiTokens...), token)
+		return nil
+	})
+	if err != nil {
+		return "", apiToken{}, err
+	}
+
+	return apiTokenPrefix + user.Uuid + "." + token.Id + "." + secret, token, nil
+}
+
+// Remove the token of the user, it cannot be used anymore
+func (s *apiTokenStore) Revoke(ctx context.Context, user *GamebaseUser, id string) error {
+	return s.users.UpdateUser(ctx, user.Email, func(current *GamebaseUser) error {
+		remaining := []apiToken{}
+		found := false
+		for _, token := range current.ApiTokens {
+			if token.Id == id {
+				found = true
+				continue
+			}
+			remaining = append(remaining, token)
+		}
+		if !found {
+			return errApiTokenNotFound
+		}
+		current.ApiTokens = remaining
+		return nil
+	})
+}
+
+// Remove all tokens of the user, see revokeAllTokens. Users which do not exist have no tokens,
+// e.g. after an email change the tokens have moved to the new address together with the user.
+func (s *apiTokenStore) RevokeAll(ctx context.Context, email string) error {
+	err := s.users.ClearUserFields(ctx, email, "api_tokens")
+	if err == errUserNotFound {
+		return nil
+	}
+	return err
+}
+
+// Check the token and return claims equivalent to an access token restricted to the scopes of the token
+func (s *apiTokenStore) Verify(ctx context.Context, raw string) (*userClaims, error) {
+	parts := strings.Split(strings.TrimPrefix(raw, apiTokenPrefix), ".")
+	if len(parts) != 3 {
+		return nil, errInvalidApiToken
+	}
+	user, err := s.users.GetUserByUuid(ctx, parts[0])
+	if err == errUserNotFound {
+		return nil, errInvalidApiToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Status.loginDenial() != "" {
+		return nil, errInvalidApiToken
+	}
+
+	hash := hashApiTokenSecret(parts[2])
+	for _, token := range user.ApiTokens {
+		if token.Id != parts[1] {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) != 1 || token.isExpired(time.Now()) {
+			return nil, errInvalidApiToken
+		}
+		return &userClaims{
+			TokenUuid:    token.Id,
+			TokenType:    apiTokenType,
+			UserEmail:    user.Email,
+			UserName:     user.Name,
+			UserGravatar: user.Gravatar,
+			UserRole:     string(user.Role.orDefault()),
+			Scopes:       token.Scopes,
+		}, nil
+	}
+	return nil, errInvalidApiToken
+}
+
+// The secrets are random, a fast hash is sufficient
+func hashApiTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// construct the ApiToken returned by the token endpoints
+func (token apiToken) toApiToken() ApiToken {
+	result := ApiToken{
+		Id:      token.Id,
+		Name:    token.Name,
+		Scopes:  token.Scopes,
+		Created: token.Created,
+	}
+	if !token.Expires.IsZero() {
+		expires := token.Expires
+		result.Expires = &expires
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+package openapi
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestApiTokenStore(t *testing.T) {
+	previous, previousRevoked := apiTokens, revokedTokens
+	t.Cleanup(func() { apiTokens, revokedTokens = previous, previousRevoked })
+	revokedTokens = &memoryTokenRevocationStore{tokens: map[string]time.Time{}, users: map[string]time.Time{}}
+	ctx := context.Background()
+
+	// a user with a fresh token in a new store
+	setup := func(t *testing.T) (UserStore, string, apiToken) {
+		users := newMemoryUserStore()
+		apiTokens = newApiTokenStore(users)
+		if err := users.SetUser(ctx, "user@example.com", GamebaseUser{Name: "user", Role: roleUser, Status: userActive}); err != nil {
+			t.Fatal(err)
+		}
+		user, _ := users.GetUser(ctx, "user@example.com")
+		raw, token, err := apiTokens.Create(ctx, user, "ci", []string{scopeGameServerRead}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return users, raw, token
+	}
+
+	tests := []struct {
+		name      string
+		change    func(t *testing.T, users UserStore, token apiToken)
+		wantEmail string // empty if the token must be invalid
+	}{
+		{"unchanged", func(t *testing.T, users UserStore, token apiToken) {}, "user@example.com"},
+		{"email changed", func(t *testing.T, users UserStore, token apiToken) {
+			if err := users.RenameUser(ctx, "user@example.com", "new@example.com"); err != nil {
+				t.Fatal(err)
+			}
+		}, "new@example.com"},
+		{"token revoked", func(t *testing.T, users UserStore, token apiToken) {
+			user, _ := users.GetUser(ctx, "user@example.com")
+			if err := apiTokens.Revoke(ctx, user, token.Id); err != nil {
+				t.Fatal(err)
+			}
+		}, ""},
+		{"all tokens revoked", func(t *testing.T, users UserStore, token apiToken) {
+			if err := revokeAllTokens(ctx, "user@example.com"); err != nil {
+				t.Fatal(err)
+			}
+		}, ""},
+		{"user disabled", func(t *testing.T, users UserStore, token apiToken) {
+			if err := users.SetUser(ctx, "user@example.com", GamebaseUser{Status: userDisabled}); err != nil {
+				t.Fatal(err)
+			}
+		}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			users, raw, token := setup(t)
+			test.change(t, users, token)
+
+			claims, err := apiTokens.Verify(ctx, raw)
+			if test.wantEmail == "" {
+				if err != errInvalidApiToken {
+					t.Errorf("Verify() error = %v, want %v", err, errInvalidApiToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserEmail != test.wantEmail || claims.TokenUuid != token.Id || claims.TokenType != apiTokenType {
+				t.Errorf("Verify() = %+v", claims)
+			}
+		})
+	}
+
+	t.Run("wrong secret", func(t *testing.T) {
+		_, raw, _ := setup(t)
+		if _, err := apiTokens.Verify(ctx, raw+"x"); err != errInvalidApiToken {
+			t.Errorf("Verify() error = %v, want %v", err, errInvalidApiToken)
+		}
+	})
+
+	t.Run("concurrent creation", func(t *testing.T) {
+		users, _, _ := setup(t)
+		user, _ := users.GetUser(ctx, "user@example.com")
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// every request has read the user before any of the tokens has been stored
+				if _, _, err := apiTokens.Create(ctx, user, "parallel", nil, time.Time{}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		updated, _ := users.GetUser(ctx, "user@example.com")
+		if len(updated.ApiTokens) != 11 {
+			t.Errorf("%d tokens stored, want 11", len(updated.ApiTokens))
+		}
+	})
+}
@@ -1,83 +1,190 @@
 package openapi
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/twinj/uuid"
-	"log"
-	"os"
 	"time"
 )
 
-func defaultSigningMethod() *jwt.SigningMethodHMAC {
-	return jwt.SigningMethodHS256
-}
+const (
+	accessTokenType       = "access"
+	refreshTokenType      = "refresh"
+	verificationTokenType = "verify"
+	resetTokenType        = "reset"
+	challengeTokenType    = "challenge"
+)
 
-func hmacSampleSecret() []byte {
-	//FIXME only call once on startup
-	key := make([]byte, 32)
-	secret := os.Getenv("ACCESS_SECRET")
-	if secret == "" {
-		_, err := rand.Read(key)
-		if err != nil {
-			log.Fatal("Could not create random hmacSampleSecret")
-		}
-		os.Setenv("ACCESS_SECRET", base64.StdEncoding.EncodeToString(key))
-		return key
-	} else {
-		readKey, err := base64.StdEncoding.DecodeString(secret)
-		if err != nil {
-			return []byte(secret)
-		}
-		return readKey
-	}
-}
+const (
+	accessTokenDuration       = time.Minute * 15
+	refreshTokenDuration      = time.Hour * 24 * 7
+	verificationTokenDuration = time.Hour * 24
+	resetTokenDuration        = time.Hour
+	challengeTokenDuration    = time.Minute * 5
+	// how long after a login actions which require the password may be confirmed without it
+	recentLoginDuration = time.Minute * 5
+)
 
 type userClaims struct {
 	TokenUuid    string `json:"token_uuid,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	RefreshUuid  string `json:"refresh_uuid,omitempty"`
 	UserEmail    string `json:"user_email,omitempty"`
 	UserName     string `json:"user_name,omitempty"`
 	UserGravatar string `json:"user_gravatar,omitempty"`
+	UserRole     string `json:"user_role,omitempty"`
+	// address the user changes to, only set in the verification token of an email change
+	NewEmail string `json:"new_email,omitempty"`
+	// fingerprint of the password the reset token has been issued for, see passwordFingerprint
+	PasswordFingerprint string `json:"password_fingerprint,omitempty"`
+	// scopes of a personal access token, never part of a JWT
+	Scopes []string `json:"-"`
+	// issue time in nanoseconds, iat only has a resolution of seconds which is too coarse for revokeAllTokens
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
+	// time of the login the token pair has been issued for, kept when the tokens are refreshed
+	AuthTime int64 `json:"auth_time,omitempty"`
 	jwt.StandardClaims
 }
 
+// Role of the user at the time the token was issued
+func (claims *userClaims) role() userRole {
+	return userRole(claims.UserRole).orDefault()
+}
+
+// Whether the user has logged in recently, tokens issued before auth_time was added never qualify
+func (claims *userClaims) isRecentLogin(now time.Time) bool {
+	return claims.AuthTime != 0 && now.Sub(time.Unix(claims.AuthTime, 0)) < recentLoginDuration
+}
+
+// Whether the request may use the scope. Only personal access tokens are restricted by scopes.
+func (claims *userClaims) hasScope(scope string) bool {
+	if claims.TokenType != apiTokenType {
+		return true
+	}
+	for _, granted := range claims.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // Create a pair jwt tokens for authentication and refresh
 func createToken(user GamebaseUser) (string, string, error) {
-	const accessDuration = time.Minute * 15
-	const refreshDuration = time.Hour * 24 * 7
+	return createSessionToken(user, time.Now().UTC().Unix())
+}
 
+// Create a pair of tokens for a session which started with the login at authTime, e.g. when refreshing
+func createSessionToken(user GamebaseUser, authTime int64) (string, string, error) {
 	now := time.Now().UTC()
+	refreshUuid := uuid.NewV4().String()
 
 	// access access
 	var err error
 	atClaims := userClaims{
 		TokenUuid:    uuid.NewV4().String(),
+		TokenType:    accessTokenType,
+		RefreshUuid:  refreshUuid,
 		UserEmail:    user.Email,
 		UserName:     user.Name,
 		UserGravatar: user.Gravatar,
+		UserRole:     string(user.Role.orDefault()),
+		IssuedAtNano: now.UnixNano(),
+		AuthTime:     authTime,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: now.Add(accessDuration).Unix(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTokenDuration).Unix(),
 		},
 	}
-	at := jwt.NewWithClaims(defaultSigningMethod(), atClaims)
-	access, err := at.SignedString(hmacSampleSecret())
+	access, err := signingKeys.sign(atClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	// refresh access
 	rtClaims := userClaims{
-		TokenUuid: uuid.NewV4().String(),
+		TokenUuid:    refreshUuid,
+		TokenType:    refreshTokenType,
+		UserEmail:    user.Email,
+		IssuedAtNano: now.UnixNano(),
+		AuthTime:     authTime,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: now.Add(refreshDuration).Unix(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(refreshTokenDuration).Unix(),
 		},
 	}
-	rt := jwt.NewWithClaims(defaultSigningMethod(), rtClaims)
-	refresh, err := rt.SignedString(hmacSampleSecret())
+	refresh, err := signingKeys.sign(rtClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	return access, refresh, nil
 }
+
+// Create the token sent to new users to verify their email address.
+// It is only valid as long as the password has not been changed, e.g. by registering again.
+func createVerificationToken(user GamebaseUser) (string, error) {
+	now := time.Now().UTC()
+	return signingKeys.sign(userClaims{
+		TokenUuid:           uuid.NewV4().String(),
+		TokenType:           verificationTokenType,
+		UserEmail:           user.Email,
+		PasswordFingerprint: passwordFingerprint(user.Password),
+		IssuedAtNano:        now.UnixNano(),
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(verificationTokenDuration).Unix(),
+		},
+	})
+}
+
+// Create the token sent to the new address of users who change their email address.
+// The account is renamed once the token is verified.
+func createEmailChangeToken(user GamebaseUser, newEmail string) (string, error) {
+	now := time.Now().UTC()
+	return signingKeys.sign(userClaims{
+		TokenUuid:           uuid.NewV4().String(),
+		TokenType:           verificationTokenType,
+		UserEmail:           user.Email,
+		NewEmail:            newEmail,
+		PasswordFingerprint: passwordFingerprint(user.Password),
+		IssuedAtNano:        now.UnixNano(),
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(verificationTokenDuration).Unix(),
+		},
+	})
+}
+
+// Create the token sent to users who forgot their password.
+// It is only valid as long as the password has not been changed.
+func createResetToken(user GamebaseUser) (string, error) {
+	now := time.Now().UTC()
+	return signingKeys.sign(userClaims{
+		TokenUuid:           uuid.NewV4().String(),
+		TokenType:           resetTokenType,
+		UserEmail:           user.Email,
+		PasswordFingerprint: passwordFingerprint(user.Password),
+		IssuedAtNano:        now.UnixNano(),
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(resetTokenDuration).Unix(),
+		},
+	})
+}
+
+// Create the token returned by the login of users with two-factor authentication,
+// which is exchanged for a token pair once the second factor has been verified
+func createChallengeToken(user GamebaseUser) (string, error) {
+	now := time.Now().UTC()
+	return signingKeys.sign(userClaims{
+		TokenUuid:           uuid.NewV4().String(),
+		TokenType:           challengeTokenType,
+		UserEmail:           user.Email,
+		PasswordFingerprint: passwordFingerprint(user.Password),
+		IssuedAtNano:        now.UnixNano(),
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(challengeTokenDuration).Unix(),
+		},
+	})
+}
@@ -0,0 +1,471 @@
+package openapi
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/twinj/uuid"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const jwtKeysSecret = "gamebase-jwt-keys"
+
+// kid of the key configured by ACCESS_SECRET
+const accessSecretKeyId = "access-secret"
+
+// Minimum time between two reloads caused by tokens with an unknown kid,
+// otherwise every request with a forged kid would read the secret
+const jwtKeyReloadInterval = time.Second * 30
+
+// set up on creation of the httpRequestAuthenticator
+var signingKeys *jwtKeyManager
+
+type jwtKey struct {
+	Id        string     `json:"kid"`
+	Algorithm string     `json:"alg"`
+	Created   time.Time  `json:"created"`
+	Retired   *time.Time `json:"retired,omitempty"`
+	// raw secret for HMAC keys, PKCS #8 encoded private key otherwise
+	Material []byte `json:"material"`
+
+	signingKey      interface{}
+	verificationKey interface{}
+}
+
+// Manages the keys used to sign and verify JWTs.
+// The first key is used for signing, retired keys are still accepted
+// until all tokens signed by them have expired.
+type jwtKeyManager struct {
+	mutex            sync.RWMutex
+	k                *kubernetesClient
+	algorithm        string
+	rotationInterval time.Duration
+	keys             []*jwtKey
+	// resourceVersion of the secret the keys have been read from, see swap
+	version    string
+	lastReload time.Time
+	// set while this replica rotates the key
+	rotating int32
+}
+
+// Load the signing keys once on startup.
+// If ACCESS_SECRET is set it is used as the only key, otherwise the keys are
+// persisted in the secret gamebase-jwt-keys and rotated every JWT_KEY_ROTATION_INTERVAL.
+func newJwtKeyManager(k kubernetesClient) *jwtKeyManager {
+	if secret := os.Getenv("ACCESS_SECRET"); secret != "" {
+		material, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			material = []byte(secret)
+		}
+		key := &jwtKey{Id: accessSecretKeyId, Algorithm: jwt.SigningMethodHS256.Alg(), Material: material}
+		if err := key.load(); err != nil {
+			log.Fatal("Could not load ACCESS_SECRET: " + err.Error())
+		}
+		return &jwtKeyManager{algorithm: key.Algorithm, keys: []*jwtKey{key}}
+	}
+
+	algorithm := os.Getenv("JWT_SIGNING_ALGORITHM")
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+	if _, err := generateJwtKey(algorithm); err != nil {
+		log.Fatal(err)
+	}
+
+	rotationInterval := time.Hour * 24 * 30
+	if interval := os.Getenv("JWT_KEY_ROTATION_INTERVAL"); interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatal("Invalid JWT_KEY_ROTATION_INTERVAL: " + err.Error())
+		}
+		rotationInterval = parsed
+	}
+
+	m := &jwtKeyManager{k: &k, algorithm: algorithm, rotationInterval: rotationInterval}
+	if err := m.reload(context.Background()); err != nil {
+		log.Fatal("Could not load JWT signing keys: " + err.Error())
+	}
+	if err := m.rotateIfDue(context.Background()); err != nil {
+		log.Fatal("Could not create JWT signing key: " + err.Error())
+	}
+	return m
+}
+
+func generateJwtKey(algorithm string) (*jwtKey, error) {
+	key := &jwtKey{
+		Id:        uuid.NewV4().String(),
+		Algorithm: algorithm,
+		Created:   time.Now().UTC(),
+	}
+
+	var err error
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		key.Material = make([]byte, 32)
+		_, err = rand.Read(key.Material)
+	case jwt.SigningMethodRS256.Alg():
+		var private *rsa.PrivateKey
+		if private, err = rsa.GenerateKey(rand.Reader, 2048); err == nil {
+			key.Material, err = x509.MarshalPKCS8PrivateKey(private)
+		}
+	case signingMethodEdDSA.Alg():
+		var private ed25519.PrivateKey
+		if _, private, err = ed25519.GenerateKey(rand.Reader); err == nil {
+			key.Material, err = x509.MarshalPKCS8PrivateKey(private)
+		}
+	default:
+		return nil, errors.New("unsupported JWT_SIGNING_ALGORITHM " + algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, key.load()
+}
+
+// Decode the key material into the keys used by jwt-go
+func (key *jwtKey) load() error {
+	if key.Algorithm == jwt.SigningMethodHS256.Alg() {
+		key.signingKey = key.Material
+		key.verificationKey = key.Material
+		return nil
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(key.Material)
+	if err != nil {
+		return err
+	}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		key.signingKey = private
+		key.verificationKey = &private.PublicKey
+	case ed25519.PrivateKey:
+		key.signingKey = private
+		key.verificationKey = private.Public()
+	default:
+		return fmt.Errorf("unsupported private key type %T", private)
+	}
+	return nil
+}
+
+// Keys are accepted until all tokens signed with them have expired
+func (key *jwtKey) isExpired(now time.Time) bool {
+	return key.Retired != nil && now.After(key.Retired.Add(refreshTokenDuration))
+}
+
+// Sign the claims with the current key and stamp its id into the kid header
+func (m *jwtKeyManager) sign(claims jwt.Claims) (string, error) {
+	if err := m.rotateIfDue(context.Background()); err != nil {
+		return "", err
+	}
+
+	m.mutex.RLock()
+	key := m.keys[0]
+	m.mutex.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.Id
+	return token.SignedString(key.signingKey)
+}
+
+// Lookup the verification key for jwt.Parse
+func (m *jwtKeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key := m.find(kid)
+	if key == nil && m.k != nil && m.startReload() {
+		// another replica might have rotated the keys
+		if err := m.reload(context.Background()); err != nil {
+			return nil, err
+		}
+		key = m.find(kid)
+	}
+
+	if key == nil || key.isExpired(time.Now().UTC()) {
+		return nil, fmt.Errorf("unknown signing key: %v", token.Header["kid"])
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verificationKey, nil
+}
+
+// Whether the keys may be reloaded for an unknown kid, at most once per jwtKeyReloadInterval
+func (m *jwtKeyManager) startReload() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(m.lastReload) < jwtKeyReloadInterval {
+		return false
+	}
+	m.lastReload = now
+	return true
+}
+
+func (m *jwtKeyManager) find(kid string) *jwtKey {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// tokens issued before key ids were introduced can only be verified with the current key
+	if kid == "" {
+		return m.keys[0]
+	}
+	for _, key := range m.keys {
+		if key.Id == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// Replace the current key if it is older than the rotation interval.
+// The kubernetes API is called without holding the mutex, so signing and verifying
+// continue with the current keys while the rotation is in progress.
+func (m *jwtKeyManager) rotateIfDue(ctx context.Context) error {
+	if m.k == nil {
+		return nil
+	}
+
+	m.mutex.RLock()
+	due := m.isRotationDue(m.keys)
+	m.mutex.RUnlock()
+	// a single rotation at a time, concurrent callers keep using the current key
+	if !due || !atomic.CompareAndSwapInt32(&m.rotating, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt32(&m.rotating, 0)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		m.mutex.RLock()
+		current, version := m.keys, m.version
+		m.mutex.RUnlock()
+
+		// another replica might have rotated the keys already
+		secret, stored, err := m.read(ctx)
+		if err != nil {
+			return err
+		}
+		if len(stored) == 0 {
+			stored = current
+		}
+		if !m.isRotationDue(stored) {
+			if secret != nil {
+				m.swap(version, stored, secret.ResourceVersion)
+			}
+			return nil
+		}
+
+		key, err := generateJwtKey(m.algorithm)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		keys := []*jwtKey{key}
+		for _, existing := range stored {
+			// the current keys are shared with concurrent readers and stay unchanged until the new keys are persisted
+			if existing.Retired == nil {
+				retired := *existing
+				retired.Retired = &now
+				existing = &retired
+			}
+			if !existing.isExpired(now) {
+				keys = append(keys, existing)
+			}
+		}
+
+		persisted, err := m.persist(ctx, secret, keys)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Rotated JWT signing key, new kid: " + key.Id)
+		m.swap(version, keys, persisted)
+		return nil
+	})
+}
+
+func (m *jwtKeyManager) isRotationDue(keys []*jwtKey) bool {
+	return len(keys) == 0 || (m.rotationInterval > 0 && time.Since(keys[0].Created) > m.rotationInterval)
+}
+
+// Replace the keys with the ones stored in the kubernetes secret
+func (m *jwtKeyManager) reload(ctx context.Context) error {
+	m.mutex.RLock()
+	version := m.version
+	m.mutex.RUnlock()
+
+	secret, keys, err := m.read(ctx)
+	if err != nil || secret == nil {
+		return err
+	}
+	m.swap(version, keys, secret.ResourceVersion)
+	return nil
+}
+
+// Install the keys read from or written to the secret with the given resourceVersion, unless the
+// keys have been replaced since expected was read (compare-and-swap). The keys installed in the
+// meantime are kept then, if they are outdated the next rotation check reads the secret again.
+func (m *jwtKeyManager) swap(expected string, keys []*jwtKey, version string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.version != expected || len(keys) == 0 {
+		return false
+	}
+	m.keys = keys
+	m.version = version
+	return true
+}
+
+// Read the keys from the kubernetes secret. The secret is nil if it does not exist yet.
+func (m *jwtKeyManager) read(ctx context.Context) (*v1.Secret, []*jwtKey, error) {
+	secret, err := m.k.GetSecret(ctx, defaultNamespace, jwtKeysSecret)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []*jwtKey
+	if err := json.Unmarshal(secret.Data["keys"], &keys); err != nil {
+		return nil, nil, err
+	}
+	for _, key := range keys {
+		if err := key.load(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return secret, keys, nil
+}
+
+// Write the keys to the kubernetes secret and return its new resourceVersion.
+// Fails with a conflict if the secret has been changed since it was read.
+func (m *jwtKeyManager) persist(ctx context.Context, secret *v1.Secret, keys []*jwtKey) (string, error) {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return "", err
+	}
+
+	if secret == nil {
+		created, err := m.k.CreateSecret(ctx, defaultNamespace, jwtKeysSecret, v1.SecretTypeOpaque, map[string]string{"keys": string(data)})
+		if apierrors.IsAlreadyExists(err) {
+			return "", apierrors.NewConflict(v1.Resource("secrets"), jwtKeysSecret, err)
+		}
+		if err != nil {
+			return "", err
+		}
+		return created.ResourceVersion, nil
+	}
+
+	secret.Data = map[string][]byte{"keys": data}
+	updated, err := m.k.UpdateSecret(ctx, defaultNamespace, secret)
+	if err != nil {
+		return "", err
+	}
+	return updated.ResourceVersion, nil
+}
+
+// A JSON Web Key as defined in RFC 7517 (public keys only)
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// The public keys of all asymmetric keys which are still accepted.
+// HMAC keys are symmetric and therefore never published.
+func (m *jwtKeyManager) jwks() jsonWebKeySet {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	now := time.Now().UTC()
+	set := jsonWebKeySet{Keys: []jsonWebKey{}}
+	for _, key := range m.keys {
+		if key.isExpired(now) {
+			continue
+		}
+		encoding := base64.RawURLEncoding
+		switch public := key.verificationKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{
+				KeyType:   "RSA",
+				KeyId:     key.Id,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				N:         encoding.EncodeToString(public.N.Bytes()),
+				E:         encoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, jsonWebKey{
+				KeyType:   "OKP",
+				KeyId:     key.Id,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         encoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
+
+// jwt-go does not support EdDSA (RFC 8037) so we provide it ourselves
+type signingMethodEd25519 struct{}
+
+var signingMethodEdDSA = &signingMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(signingMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return signingMethodEdDSA
+	})
+}
+
+func (m *signingMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(private, []byte(signingString))), nil
+}
+
+func (m *signingMethodEd25519) Verify(signingString string, signature string, key interface{}) error {
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	decoded, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(public, []byte(signingString), decoded) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"testing"
+)
+
+func TestJwtKeyManagerSwap(t *testing.T) {
+	first := &jwtKey{Id: "first"}
+	second := &jwtKey{Id: "second"}
+	tests := []struct {
+		name        string
+		expected    string
+		keys        []*jwtKey
+		wantSwapped bool
+		wantKey     string
+		wantVersion string
+	}{
+		{"unchanged since read", "1", []*jwtKey{second}, true, "second", "2"},
+		{"replaced concurrently", "0", []*jwtKey{second}, false, "first", "1"},
+		{"no keys", "1", nil, false, "first", "1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := &jwtKeyManager{keys: []*jwtKey{first}, version: "1"}
+			if swapped := m.swap(test.expected, test.keys, "2"); swapped != test.wantSwapped {
+				t.Errorf("swap() = %v, want %v", swapped, test.wantSwapped)
+			}
+			if m.keys[0].Id != test.wantKey || m.version != test.wantVersion {
+				t.Errorf("keys %s version %s, want %s version %s", m.keys[0].Id, m.version, test.wantKey, test.wantVersion)
+			}
+		})
+	}
+}
+
+func TestJwtKeyManagerSignAndVerify(t *testing.T) {
+	key, err := generateJwtKey(signingMethodEdDSA.Alg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &jwtKeyManager{algorithm: key.Algorithm, keys: []*jwtKey{key}}
+	signed, err := m.sign(userClaims{UserEmail: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		keyFunc jwt.Keyfunc
+		wantErr bool
+	}{
+		{"current key", m.keyFunc, false},
+		{"unknown key", (&jwtKeyManager{keys: []*jwtKey{{Id: "other", Algorithm: key.Algorithm}}}).keyFunc, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := jwt.ParseWithClaims(signed, &userClaims{}, test.keyFunc)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ParseWithClaims() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package openapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/twinj/uuid"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oidcStateTokenType     = "oidc_state"
+	oidcStateTokenDuration = time.Minute * 10
+	// the cookie keeps the state, nonce and PKCE verifier between the redirect to the provider and the callback
+	oidcStateCookie = "gamebase_oidc"
+	oidcCookiePath  = "/auth/oidc"
+
+	oidcRequestTimeout = 10 * time.Second
+	// tolerated clock difference to the identity provider
+	oidcClockSkew = time.Minute
+	// unknown key ids trigger a reload of the provider keys at most this often
+	oidcKeysReloadInterval = time.Minute
+)
+
+// set up on creation of the httpRequestAuthenticator, nil if OIDC login is not configured
+var oidc *oidcProvider
+
+// Login through an external OpenID Connect identity provider using the authorization code flow with PKCE
+type oidcProvider struct {
+	issuer       string
+	clientId     string
+	clientSecret string
+	redirectUrl  string
+	scopes       string
+	client       *http.Client
+
+	mutex      sync.Mutex
+	discovery  *oidcDiscovery
+	keys       map[string]interface{}
+	keysLoaded time.Time
+}
+
+// The parts of the provider metadata (OpenID Connect Discovery 1.0) used by the login
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// Kept in the state cookie, signed like the GameBase tokens
+type oidcStateClaims struct {
+	TokenType    string `json:"token_type,omitempty"`
+	State        string `json:"state,omitempty"`
+	Nonce        string `json:"nonce,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	jwt.StandardClaims
+}
+
+// The verified identity of the user returned by the provider
+type oidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// The provider is configured by OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET,
+// OIDC_REDIRECT_URL and OIDC_SCOPES. Returns nil if OIDC_ISSUER is not set.
+func newOidcProvider() *oidcProvider {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+	clientId := os.Getenv("OIDC_CLIENT_ID")
+	if clientId == "" {
+		panic("OIDC_CLIENT_ID is required for the OIDC login")
+	}
+	redirectUrl := os.Getenv("OIDC_REDIRECT_URL")
+	if redirectUrl == "" {
+		redirectUrl = publicUrl() + oidcCookiePath + "/callback"
+	}
+	scopes := os.Getenv("OIDC_SCOPES")
+	if scopes == "" {
+		scopes = "openid email profile"
+	}
+	return &oidcProvider{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientId:     clientId,
+		clientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		redirectUrl:  redirectUrl,
+		scopes:       scopes,
+		client:       &http.Client{Timeout: oidcRequestTimeout},
+	}
+}
+
+// Start a login. Returns the URL of the provider the user has to be redirected to
+// and the state token which has to be passed to the callback.
+func (p *oidcProvider) startLogin(ctx context.Context) (string, string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomUrlToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomUrlToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomUrlToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+	stateToken, err := signingKeys.sign(oidcStateClaims{
+		TokenType:    oidcStateTokenType,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewV4().String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(oidcStateTokenDuration).Unix(),
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientId)
+	query.Set("redirect_uri", p.redirectUrl)
+	query.Set("scope", p.scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), stateToken, nil
+}
+
+// Finish a login: check the state, exchange the code and verify the returned ID token
+func (p *oidcProvider) finishLogin(ctx context.Context, stateToken string, state string, code string) (*oidcIdentity, error) {
+	claims := &oidcStateClaims{}
+	token, err := jwt.ParseWithClaims(stateToken, claims, signingKeys.keyFunc)
+	if err != nil || !token.Valid || claims.TokenType != oidcStateTokenType {
+		return nil, errors.New("invalid or expired login state")
+	}
+	if state == "" || state != claims.State {
+		return nil, errors.New("invalid or expired login state")
+	}
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+
+	idToken, err := p.exchange(ctx, code, claims.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.verifyIdToken(ctx, idToken, claims.Nonce)
+}
+
+// Fetch the provider metadata, it is cached after the first successful request
+func (p *oidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := p.getJson(ctx, p.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("identity provider reported issuer %q instead of %q", discovery.Issuer, p.issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		return nil, errors.New("incomplete identity provider metadata")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// Exchange the authorization code for the ID token at the token endpoint
+func (p *oidcProvider) exchange(ctx context.Context, code string, verifier string) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectUrl)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", p.clientId)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.clientId), url.QueryEscape(p.clientSecret))
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response of the identity provider: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("identity provider rejected the authorization code: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IdToken == "" {
+		return "", errors.New("identity provider did not return an ID token")
+	}
+	return body.IdToken, nil
+}
+
+// Verify signature, issuer, audience, expiry and nonce of the ID token
+func (p *oidcProvider) verifyIdToken(ctx context.Context, raw string, nonce string) (*oidcIdentity, error) {
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}}
+	claims := &oidcIdTokenClaims{}
+	_, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != p.issuer {
+		return nil, errors.New("invalid ID token: unexpected issuer")
+	}
+	if !claims.Audience.contains(p.clientId) {
+		return nil, errors.New("invalid ID token: unexpected audience")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.clientId {
+		return nil, errors.New("invalid ID token: unexpected authorized party")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid ID token: missing subject")
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	return &oidcIdentity{
+		Subject:       claims.Subject,
+		Email:         normalizeEmail(claims.Email),
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+		Name:          name,
+	}, nil
+}
+
+// Lookup the provider key, reloading the keys if the provider has rotated them
+func (p *oidcProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key, exists := p.keys[kid]; exists {
+		return key, nil
+	}
+	if time.Since(p.keysLoaded) < oidcKeysReloadInterval {
+		return nil, fmt.Errorf("unknown signing key: %v", kid)
+	}
+	if p.discovery == nil {
+		return nil, errors.New("identity provider metadata not loaded")
+	}
+
+	var set jsonWebKeySet
+	if err := p.getJson(ctx, p.discovery.JwksUri, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// keys of unsupported types are skipped
+		if public, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyId] = public
+		}
+	}
+	p.keys = keys
+	p.keysLoaded = time.Now()
+
+	if key, exists := p.keys[kid]; exists {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key: %v", kid)
+}
+
+func (p *oidcProvider) getJson(ctx context.Context, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("identity provider answered %s with %d", url, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// Decode the public key of an RSA or EC JSON Web Key
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	encoding := base64.RawURLEncoding
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := encoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := encoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %v", jwk.Curve)
+		}
+		x, err := encoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := encoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		public := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return public, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", jwk.KeyType)
+	}
+}
+
+// jwt.StandardClaims cannot be used since the audience may be a list
+type oidcIdTokenClaims struct {
+	Issuer            string       `json:"iss"`
+	Subject           string       `json:"sub"`
+	Audience          oidcAudience `json:"aud"`
+	AuthorizedParty   string       `json:"azp"`
+	ExpiresAt         int64        `json:"exp"`
+	IssuedAt          int64        `json:"iat"`
+	Nonce             string       `json:"nonce"`
+	Email             string       `json:"email"`
+	EmailVerified     *bool        `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+}
+
+func (claims *oidcIdTokenClaims) Valid() error {
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.Add(-oidcClockSkew).Unix() > claims.ExpiresAt {
+		return errors.New("token is expired")
+	}
+	if claims.IssuedAt != 0 && now.Add(oidcClockSkew).Unix() < claims.IssuedAt {
+		return errors.New("token used before issued")
+	}
+	return nil
+}
+
+// The aud claim is either a single string or a list of strings
+type oidcAudience []string
+
+func (audience *oidcAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = oidcAudience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*audience = list
+	return nil
+}
+
+func (audience oidcAudience) contains(clientId string) bool {
+	for _, value := range audience {
+		if value == clientId {
+			return true
+		}
+	}
+	return false
+}
+
+// Random value for state, nonce and PKCE verifier
+func randomUrlToken() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+var (
+	errOidcEmailMissing    = errors.New("the identity provider did not return an email address")
+	errOidcEmailUnverified = errors.New("the email address has not been verified by the identity provider")
+	errOidcSubjectMismatch = errors.New("the email address is linked to another account of the identity provider")
+	errOidcRegistration    = errors.New("new users cannot register through the identity provider, registration is restricted")
+)
+
+// Map the identity returned by the OIDC provider to a user. Users are looked up by email and
+// then by subject, so users keep their account if their email changes at the provider.
+// Existing users are linked to the provider on their first OIDC login and new users are
+// created together with their namespace.
+func provisionOidcUser(ctx context.Context, cl kubernetesClient, users UserStore, identity oidcIdentity) (*GamebaseUser, error) {
+	if identity.Email == "" {
+		return nil, errOidcEmailMissing
+	}
+
+	user, err := users.GetUser(ctx, identity.Email)
+	if err == errUserNotFound {
+		user, err = findOidcUser(ctx, users, identity.Subject)
+	}
+	if err != nil && err != errUserNotFound {
+		return nil, err
+	}
+
+	if err == errUserNotFound {
+		// invitation codes cannot be passed through the provider
+		if registration != registrationOpen {
+			return nil, errOidcRegistration
+		}
+		if !identity.EmailVerified {
+			return nil, errOidcEmailUnverified
+		}
+		// the user logs in through the provider only, the password is unknown until it is reset
+		password, err := generateTemporaryPassword()
+		if err != nil {
+			return nil, err
+		}
+		hashedPassword, err := hashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		name := identity.Name
+		if name == "" {
+			name = strings.SplitN(identity.Email, "@", 2)[0]
+		}
+		// the user stays pending until the namespace has been created
+		err = users.SetUser(ctx, identity.Email, GamebaseUser{
+			Name:        name,
+			Password:    hashedPassword,
+			Role:        roleUser,
+			Status:      userPending,
+			OidcSubject: identity.Subject,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if user, err = users.GetUser(ctx, identity.Email); err != nil {
+			return nil, err
+		}
+	} else if user.OidcSubject == "" {
+		if !identity.EmailVerified {
+			return nil, errOidcEmailUnverified
+		}
+		link := GamebaseUser{OidcSubject: identity.Subject}
+		if user.Status == userPending {
+			// whoever registered the pending account has not proven to own the address
+			password, err := generateTemporaryPassword()
+			if err != nil {
+				return nil, err
+			}
+			if link.Password, err = hashPassword(password); err != nil {
+				return nil, err
+			}
+		}
+		if err := users.SetUser(ctx, user.Email, link); err != nil {
+			return nil, err
+		}
+		user.OidcSubject = identity.Subject
+	} else if user.OidcSubject != identity.Subject {
+		return nil, errOidcSubjectMismatch
+	}
+
+	if user.Status == userPending {
+		if err := ensureUserNamespace(ctx, cl, user.Uuid); err != nil {
+			return nil, err
+		}
+		if err := users.SetUser(ctx, user.Email, GamebaseUser{Status: userActive}); err != nil {
+			return nil, err
+		}
+		user.Status = userActive
+		// the provider has verified the email address
+		if err := promoteConfiguredAdmin(ctx, users, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// Lookup the user linked to the subject of the OIDC provider
+func findOidcUser(ctx context.Context, users UserStore, subject string) (*GamebaseUser, error) {
+	list, err := users.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].OidcSubject == subject {
+			return &list[i], nil
+		}
+	}
+	return nil, errUserNotFound
+}
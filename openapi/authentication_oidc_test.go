@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Identity provider issuing codes for the logins started by the test
+type mockIdentityProvider struct {
+	server *httptest.Server
+	keys   *jwtKeyManager
+
+	mutex sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// PKCE challenge and nonce of the login an authorization code has been issued for
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+func newMockIdentityProvider(t *testing.T) *mockIdentityProvider {
+	key, err := generateJwtKey(jwt.SigningMethodRS256.Alg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdentityProvider{keys: &jwtKeyManager{algorithm: key.Algorithm, keys: []*jwtKey{key}}, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JwksUri:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idp.keys.jwks())
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// Issue an authorization code for the login the user has been redirected with
+func (idp *mockIdentityProvider) authorize(location string) (string, string, error) {
+	redirect, err := url.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+	query := redirect.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		return "", "", errors.New("authorization request without PKCE: " + location)
+	}
+
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+	code := "code-" + strconv.Itoa(len(idp.codes))
+	idp.codes[code] = mockAuthorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return query.Get("state"), code, nil
+}
+
+func (idp *mockIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	idp.mutex.Lock()
+	issued, exists := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mutex.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !exists || base64.RawURLEncoding.EncodeToString(challenge[:]) != issued.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(gin.H{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := idp.keys.sign(jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "subject-1",
+		"aud":            r.PostFormValue("client_id"),
+		"exp":            now.Add(time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          issued.nonce,
+		"email":          " User@Example.com",
+		"email_verified": true,
+		"name":           "User",
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(gin.H{"id_token": idToken})
+}
+
+// Passes the identity checked by the authenticator back to the test
+type oidcIdentityRecorder struct {
+	httpRequestHandler
+}
+
+func (hr oidcIdentityRecorder) OidcCallback(c *gin.Context) {
+	c.JSON(http.StatusOK, c.MustGet("identity"))
+}
+
+func TestOidcLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previousKeys, previousOidc := signingKeys, oidc
+	t.Cleanup(func() { signingKeys, oidc = previousKeys, previousOidc })
+
+	key, err := generateJwtKey(jwt.SigningMethodHS256.Alg())
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKeys = &jwtKeyManager{algorithm: key.Algorithm, keys: []*jwtKey{key}}
+	idp := newMockIdentityProvider(t)
+	oidc = &oidcProvider{
+		issuer:      idp.server.URL,
+		clientId:    "gamebase",
+		redirectUrl: "http://localhost/auth/oidc/callback",
+		scopes:      "openid email profile",
+		client:      idp.server.Client(),
+	}
+
+	hr := &httpRequestAuthenticator{nextHandler: oidcIdentityRecorder{}}
+	router := gin.New()
+	router.GET("/auth/oidc/login", hr.OidcLogin)
+	router.GET("/auth/oidc/callback", hr.OidcCallback)
+
+	// start a login and let the provider issue a code for it
+	login := func() (*http.Cookie, string, string) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+		if recorder.Code != http.StatusFound {
+			t.Fatalf("login status %d, want %d", recorder.Code, http.StatusFound)
+		}
+		cookies := recorder.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || !cookies[0].HttpOnly {
+			t.Fatalf("login cookies %v, want the http only state cookie", cookies)
+		}
+		state, code, err := idp.authorize(recorder.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cookies[0], state, code
+	}
+
+	cookie, state, code := login()
+	_, otherState, otherCode := login()
+
+	tests := []struct {
+		name       string
+		cookie     *http.Cookie
+		query      url.Values
+		wantStatus int
+	}{
+		{"state mismatch", cookie, url.Values{"state": {otherState}, "code": {code}}, http.StatusUnauthorized},
+		{"missing state cookie", nil, url.Values{"state": {state}, "code": {code}}, http.StatusBadRequest},
+		{"provider error", cookie, url.Values{"error": {"access_denied"}}, http.StatusUnauthorized},
+		{"code of another login", cookie, url.Values{"state": {state}, "code": {otherCode}}, http.StatusUnauthorized},
+		{"valid login", cookie, url.Values{"state": {state}, "code": {code}}, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+test.query.Encode(), nil)
+			if test.cookie != nil {
+				request.AddCookie(test.cookie)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("callback status %d, want %d: %s", recorder.Code, test.wantStatus, recorder.Body.String())
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+			var identity oidcIdentity
+			if err := json.Unmarshal(recorder.Body.Bytes(), &identity); err != nil {
+				t.Fatal(err)
+			}
+			want := oidcIdentity{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "User"}
+			if identity != want {
+				t.Errorf("identity %+v, want %+v", identity, want)
+			}
+		})
+	}
+}
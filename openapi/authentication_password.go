@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// argon2id parameters used for newly hashed passwords.
+// Hashes created with different parameters are still accepted but get rehashed on the next login.
+const (
+	passwordHashAlgorithm = "argon2id"
+	passwordHashTime      = 1
+	passwordHashMemory    = 64 * 1024
+	passwordHashThreads   = 4
+	passwordHashKeyLength = 32
+	passwordSaltLength    = 16
+)
+
+type passwordHash struct {
+	version uint32
+	time    uint32
+	memory  uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// Hash the password using argon2id with a random salt.
+// The result contains all parameters needed for verification
+// in the format $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := passwordHash{
+		version: argon2.Version,
+		time:    passwordHashTime,
+		memory:  passwordHashMemory,
+		threads: passwordHashThreads,
+		salt:    salt,
+	}
+	hash.key = hash.derive(password, passwordHashKeyLength)
+	return hash.encode(), nil
+}
+
+// Check the password against the stored password.
+// The second return value reports if the stored password is outdated (plaintext or old parameters)
+// and should be replaced by a fresh hash.
+func verifyPassword(stored string, password string) (bool, bool) {
+	if stored == "" {
+		return false, false
+	}
+
+	// passwords created before hashing was introduced are stored in plaintext
+	if !strings.HasPrefix(stored, "$"+passwordHashAlgorithm+"$") {
+		valid := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return valid, valid
+	}
+
+	hash, err := decodePasswordHash(stored)
+	if err != nil {
+		return false, false
+	}
+
+	key := hash.derive(password, uint32(len(hash.key)))
+	if subtle.ConstantTimeCompare(hash.key, key) != 1 {
+		return false, false
+	}
+
+	return true, !hash.isCurrent()
+}
+
+func (h passwordHash) derive(password string, keyLength uint32) []byte {
+	return argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, keyLength)
+}
+
+func (h passwordHash) isCurrent() bool {
+	return h.version == argon2.Version &&
+		h.time == passwordHashTime &&
+		h.memory == passwordHashMemory &&
+		h.threads == passwordHashThreads &&
+		len(h.key) == passwordHashKeyLength
+}
+
+func (h passwordHash) encode() string {
+	encoding := base64.RawStdEncoding
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		passwordHashAlgorithm, h.version, h.memory, h.time, h.threads,
+		encoding.EncodeToString(h.salt), encoding.EncodeToString(h.key))
+}
+
+func decodePasswordHash(encoded string) (*passwordHash, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 6 || fields[1] != passwordHashAlgorithm {
+		return nil, errors.New("invalid password hash format")
+	}
+
+	var hash passwordHash
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &hash.version); err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads); err != nil {
+		return nil, err
+	}
+
+	var err error
+	encoding := base64.RawStdEncoding
+	if hash.salt, err = encoding.DecodeString(fields[4]); err != nil {
+		return nil, err
+	}
+	if hash.key, err = encoding.DecodeString(fields[5]); err != nil {
+		return nil, err
+	}
+	if len(hash.key) == 0 {
+		return nil, errors.New("invalid password hash format")
+	}
+
+	return &hash, nil
+}
+
+// Generate a random password which is handed out to the user once, e.g. by an admin
+func generateTemporaryPassword() (string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// Short fingerprint of the stored password hash. Tokens carrying the fingerprint
+// become invalid as soon as the password is changed.
+func passwordFingerprint(stored string) string {
+	sum := sha256.Sum256([]byte(stored))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,62 @@
+package openapi
+
+import (
+	"golang.org/x/crypto/argon2"
+	"testing"
+)
+
+func TestHashPasswordUsesRandomSalt(t *testing.T) {
+	first, err := hashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := hashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("hashes of the same password are equal: %s", first)
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	current, err := hashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdated := passwordHash{
+		version: argon2.Version,
+		time:    passwordHashTime + 1,
+		memory:  passwordHashMemory,
+		threads: passwordHashThreads,
+		salt:    []byte("0123456789abcdef"),
+	}
+	outdated.key = outdated.derive("secret", passwordHashKeyLength)
+
+	tests := []struct {
+		name         string
+		stored       string
+		password     string
+		wantValid    bool
+		wantOutdated bool
+	}{
+		{"current hash", current, "secret", true, false},
+		{"current hash wrong password", current, "wrong", false, false},
+		{"current hash empty password", current, "", false, false},
+		{"outdated parameters", outdated.encode(), "secret", true, true},
+		{"outdated parameters wrong password", outdated.encode(), "wrong", false, false},
+		{"plaintext", "secret", "secret", true, true},
+		{"plaintext wrong password", "secret", "wrong", false, false},
+		{"no password", "", "", false, false},
+		{"malformed hash", "$argon2id$v=19$m=65536$salt$key", "secret", false, false},
+		{"empty key", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$", "", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			valid, outdated := verifyPassword(test.stored, test.password)
+			if valid != test.wantValid || outdated != test.wantOutdated {
+				t.Errorf("verifyPassword() = %v, %v, want %v, %v", valid, outdated, test.wantValid, test.wantOutdated)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tokenRevocationConfigMap = "gamebase-token-revocations"
+
+// How long the kubernetes store answers from its copy of the ConfigMap. Revocations by other
+// replicas take effect after at most this time, those of this replica immediately.
+const tokenRevocationCacheDuration = time.Second * 5
+
+// Store for tokens which have been invalidated before they expired
+type tokenRevocationStore interface {
+	// Revoke a single token identified by its uuid until it expires
+	Revoke(ctx context.Context, tokenUuid string, expiresAt time.Time) error
+	// Revoke all tokens of the user which have been issued before the given time
+	RevokeAll(ctx context.Context, email string, issuedBefore time.Time) error
+	// Check if the token has been revoked
+	IsRevoked(ctx context.Context, claims *userClaims) (bool, error)
+}
+
+// set up on creation of the httpRequestAuthenticator
+var revokedTokens tokenRevocationStore
+
+// Select the revocation store based on the TOKEN_REVOCATION_STORE environment variable
+func newTokenRevocationStore(k kubernetesClient) tokenRevocationStore {
+	switch store := os.Getenv("TOKEN_REVOCATION_STORE"); store {
+	case "", "memory":
+		return newMemoryTokenRevocationStore()
+	case "kubernetes":
+		return &kubernetesTokenRevocationStore{k: k}
+	default:
+		panic("Unknown TOKEN_REVOCATION_STORE " + store)
+	}
+}
+
+// Revoke every token of the user issued until now (log out all sessions).
+// The personal access tokens are removed since they do not expire with the sessions.
+func revokeAllTokens(ctx context.Context, email string) error {
+	if err := revokedTokens.RevokeAll(ctx, email, time.Now().UTC()); err != nil {
+		return err
+	}
+	return apiTokens.RevokeAll(ctx, email)
+}
+
+// Revoke the access token and the refresh token issued together with it (log out the session)
+func revokeTokenPair(ctx context.Context, claims *userClaims) error {
+	if err := revokedTokens.Revoke(ctx, claims.TokenUuid, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+	if claims.RefreshUuid != "" {
+		refreshExpiry := time.Unix(claims.IssuedAt, 0).Add(refreshTokenDuration)
+		return revokedTokens.Revoke(ctx, claims.RefreshUuid, refreshExpiry)
+	}
+	return nil
+}
+
+// Tokens issued after the cutoff stay valid, even within the same second
+func isRevokedByCutoff(claims *userClaims, cutoff time.Time) bool {
+	if claims.IssuedAtNano == 0 {
+		// tokens issued before iat_ns was added only have a resolution of seconds,
+		// those issued during the second of the cutoff are revoked to be safe
+		return claims.IssuedAt <= cutoff.Unix()
+	}
+	return claims.IssuedAtNano < cutoff.UnixNano()
+}
+
+// In-memory revocation store. Revocations are lost on restart and not shared between replicas.
+type memoryTokenRevocationStore struct {
+	mutex  sync.RWMutex
+	tokens map[string]time.Time
+	users  map[string]time.Time
+}
+
+func newMemoryTokenRevocationStore() *memoryTokenRevocationStore {
+	store := &memoryTokenRevocationStore{
+		tokens: map[string]time.Time{},
+		users:  map[string]time.Time{},
+	}
+	go store.evictPeriodically(time.Minute)
+	return store
+}
+
+func (s *memoryTokenRevocationStore) Revoke(ctx context.Context, tokenUuid string, expiresAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[tokenUuid] = expiresAt
+	return nil
+}
+
+func (s *memoryTokenRevocationStore) RevokeAll(ctx context.Context, email string, issuedBefore time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users[email] = issuedBefore
+	return nil
+}
+
+func (s *memoryTokenRevocationStore) IsRevoked(ctx context.Context, claims *userClaims) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if _, revoked := s.tokens[claims.TokenUuid]; revoked {
+		return true, nil
+	}
+	if cutoff, exists := s.users[claims.UserEmail]; exists && isRevokedByCutoff(claims, cutoff) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Remove revocations of tokens which are expired anyway
+func (s *memoryTokenRevocationStore) evict(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for tokenUuid, expiresAt := range s.tokens {
+		if now.After(expiresAt) {
+			delete(s.tokens, tokenUuid)
+		}
+	}
+	for email, cutoff := range s.users {
+		if now.After(cutoff.Add(refreshTokenDuration)) {
+			delete(s.users, email)
+		}
+	}
+}
+
+func (s *memoryTokenRevocationStore) evictPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for now := range ticker.C {
+		s.evict(now.UTC())
+	}
+}
+
+// Revocation store persisted in a ConfigMap so revocations survive restarts and are shared between replicas.
+// Keys are "token.<uuid>" and "user.<encoded email>" with the expiry or cutoff time as value.
+// The ConfigMap is cached for tokenRevocationCacheDuration so not every request reads it.
+type kubernetesTokenRevocationStore struct {
+	k       kubernetesClient
+	mutex   sync.RWMutex
+	entries map[string]string
+	fetched time.Time
+}
+
+func (s *kubernetesTokenRevocationStore) Revoke(ctx context.Context, tokenUuid string, expiresAt time.Time) error {
+	return s.update(ctx, "token."+tokenUuid, expiresAt)
+}
+
+func (s *kubernetesTokenRevocationStore) RevokeAll(ctx context.Context, email string, issuedBefore time.Time) error {
+	return s.update(ctx, "user."+encodeEmail(email), issuedBefore)
+}
+
+func (s *kubernetesTokenRevocationStore) IsRevoked(ctx context.Context, claims *userClaims) (bool, error) {
+	entries, err := s.cachedEntries(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if _, revoked := entries["token."+claims.TokenUuid]; revoked {
+		return true, nil
+	}
+	if value, exists := entries["user."+encodeEmail(claims.UserEmail)]; exists {
+		cutoff, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return false, fmt.Errorf("invalid revocation entry for %s: %v", claims.UserEmail, err)
+		}
+		return isRevokedByCutoff(claims, cutoff), nil
+	}
+	return false, nil
+}
+
+// The entries of the ConfigMap, read again once the cached copy is older than tokenRevocationCacheDuration
+func (s *kubernetesTokenRevocationStore) cachedEntries(ctx context.Context) (map[string]string, error) {
+	s.mutex.RLock()
+	entries, fetched := s.entries, s.fetched
+	s.mutex.RUnlock()
+	if entries != nil && time.Since(fetched) < tokenRevocationCacheDuration {
+		return entries, nil
+	}
+
+	configMap, err := s.k.Client.CoreV1().ConfigMaps(defaultNamespace).Get(ctx, tokenRevocationConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap, err = &v1.ConfigMap{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.cache(configMap.Data)
+	return configMap.Data, nil
+}
+
+// Replace the cached entries, the map must not be modified afterwards
+func (s *kubernetesTokenRevocationStore) cache(entries map[string]string) {
+	if entries == nil {
+		entries = map[string]string{}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries = entries
+	s.fetched = time.Now()
+}
+
+// Add the entry to the ConfigMap and drop all entries which are no longer needed
+func (s *kubernetesTokenRevocationStore) update(ctx context.Context, key string, value time.Time) error {
+	configMaps := s.k.Client.CoreV1().ConfigMaps(defaultNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, tokenRevocationConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap, err = configMaps.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: tokenRevocationConfigMap},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		now := time.Now().UTC()
+		for existingKey, existingValue := range configMap.Data {
+			if expiry, err := time.Parse(time.RFC3339Nano, existingValue); err == nil {
+				if strings.HasPrefix(existingKey, "user.") {
+					expiry = expiry.Add(refreshTokenDuration)
+				}
+				if now.After(expiry) {
+					delete(configMap.Data, existingKey)
+				}
+			}
+		}
+		configMap.Data[key] = value.UTC().Format(time.RFC3339Nano)
+
+		updated, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		// the revocation has to take effect immediately, at least on this replica
+		s.cache(updated.Data)
+		return nil
+	})
+}
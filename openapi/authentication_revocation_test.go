@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"testing"
+	"time"
+)
+
+func TestIsRevokedByCutoff(t *testing.T) {
+	cutoff := time.Date(2020, 7, 1, 12, 0, 0, 500000000, time.UTC)
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		// tokens issued before iat_ns was added only carry iat
+		legacy bool
+		want   bool
+	}{
+		{"issued before", cutoff.Add(-time.Minute), false, true},
+		{"issued earlier in the same second", cutoff.Add(-time.Millisecond), false, true},
+		{"issued at the cutoff", cutoff, false, false},
+		{"issued later in the same second", cutoff.Add(time.Millisecond), false, false},
+		{"issued after", cutoff.Add(time.Minute), false, false},
+		{"legacy issued before", cutoff.Add(-time.Minute), true, true},
+		{"legacy issued in the same second", cutoff.Add(time.Millisecond), true, true},
+		{"legacy issued after", cutoff.Add(time.Second), true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := &userClaims{StandardClaims: jwt.StandardClaims{IssuedAt: test.issuedAt.Unix()}}
+			if !test.legacy {
+				claims.IssuedAtNano = test.issuedAt.UnixNano()
+			}
+			if revoked := isRevokedByCutoff(claims, cutoff); revoked != test.want {
+				t.Errorf("isRevokedByCutoff() = %v, want %v", revoked, test.want)
+			}
+		})
+	}
+}
+
+func TestRevokeAllTokens(t *testing.T) {
+	previous, previousApiTokens := revokedTokens, apiTokens
+	t.Cleanup(func() { revokedTokens, apiTokens = previous, previousApiTokens })
+	revokedTokens = &memoryTokenRevocationStore{tokens: map[string]time.Time{}, users: map[string]time.Time{}}
+	apiTokens = newApiTokenStore(newMemoryUserStore())
+	ctx := context.Background()
+	claimsAt := func(email string, issuedAt time.Time) *userClaims {
+		return &userClaims{
+			UserEmail:      email,
+			IssuedAtNano:   issuedAt.UnixNano(),
+			StandardClaims: jwt.StandardClaims{IssuedAt: issuedAt.Unix()},
+		}
+	}
+
+	before := claimsAt("user@example.com", time.Now())
+	other := claimsAt("other@example.com", time.Now())
+	if err := revokeAllTokens(ctx, "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// a login right after logging out all sessions, usually within the same second
+	after := claimsAt("user@example.com", time.Now())
+
+	tests := []struct {
+		name   string
+		claims *userClaims
+		want   bool
+	}{
+		{"issued before the revocation", before, true},
+		{"issued after the revocation", after, false},
+		{"other user", other, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			revoked, err := revokedTokens.IsRevoked(ctx, test.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != test.want {
+				t.Errorf("IsRevoked() = %v, want %v", revoked, test.want)
+			}
+		})
+	}
+}
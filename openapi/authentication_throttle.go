@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Failed logins which are allowed before the backoff starts
+const (
+	accountFreeAttempts = 5
+	ipFreeAttempts      = 20
+)
+
+const (
+	loginBackoffBase = time.Second
+	// failed attempts are forgotten after this time without further failures
+	loginAttemptsRetention = 24 * time.Hour
+)
+
+// set up on creation of the httpRequestAuthenticator
+var loginAttempts *loginThrottle
+
+// Tracks failed logins per account and per client IP and locks them out with exponential backoff.
+// The attempts are kept in memory and are not shared between replicas.
+type loginThrottle struct {
+	mutex       sync.Mutex
+	maxLockout  time.Duration
+	accounts    map[string]*failedLogins
+	clientAddrs map[string]*failedLogins
+}
+
+type failedLogins struct {
+	count       int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// The maximum lockout is configured by LOGIN_MAX_LOCKOUT, e.g. 15m (default)
+func newLoginThrottle() *loginThrottle {
+	maxLockout := 15 * time.Minute
+	if value := os.Getenv("LOGIN_MAX_LOCKOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			panic("Invalid LOGIN_MAX_LOCKOUT: " + err.Error())
+		}
+		maxLockout = parsed
+	}
+	throttle := &loginThrottle{
+		maxLockout:  maxLockout,
+		accounts:    map[string]*failedLogins{},
+		clientAddrs: map[string]*failedLogins{},
+	}
+	go throttle.evictPeriodically(time.Hour)
+	return throttle
+}
+
+// Check if logins for the account or from the client are currently locked.
+// Returns how long the caller has to wait.
+func (t *loginThrottle) Locked(email string, clientIp string) (bool, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	wait := time.Duration(0)
+	for _, attempts := range []*failedLogins{t.accounts[email], t.clientAddrs[clientIp]} {
+		if attempts != nil && attempts.lockedUntil.After(now) && attempts.lockedUntil.Sub(now) > wait {
+			wait = attempts.lockedUntil.Sub(now)
+		}
+	}
+	return wait > 0, wait
+}
+
+// Record a failed login for the account and the client
+func (t *loginThrottle) Failed(email string, clientIp string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	t.record(t.accounts, email, accountFreeAttempts, now)
+	t.record(t.clientAddrs, clientIp, ipFreeAttempts, now)
+}
+
+// Reset the failed logins of the account after a successful login
+func (t *loginThrottle) Succeeded(email string) {
+	t.Unlock(email)
+}
+
+// Lift the lockout of the account, e.g. by an admin
+func (t *loginThrottle) Unlock(email string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.accounts, email)
+}
+
+func (t *loginThrottle) record(attemptsByKey map[string]*failedLogins, key string, freeAttempts int, now time.Time) {
+	attempts, exists := attemptsByKey[key]
+	if !exists {
+		attempts = &failedLogins{}
+		attemptsByKey[key] = attempts
+	}
+	attempts.count++
+	attempts.lastFailure = now
+	if attempts.count > freeAttempts {
+		attempts.lockedUntil = now.Add(t.backoff(attempts.count - freeAttempts))
+	}
+}
+
+// Lockout doubling with every failed attempt, limited to the maximum lockout
+func (t *loginThrottle) backoff(exceeded int) time.Duration {
+	backoff := loginBackoffBase
+	for i := 1; i < exceeded && backoff < t.maxLockout; i++ {
+		backoff *= 2
+	}
+	if backoff > t.maxLockout {
+		return t.maxLockout
+	}
+	return backoff
+}
+
+func (t *loginThrottle) evict(now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, attemptsByKey := range []map[string]*failedLogins{t.accounts, t.clientAddrs} {
+		for key, attempts := range attemptsByKey {
+			if now.Sub(attempts.lastFailure) > loginAttemptsRetention && now.After(attempts.lockedUntil) {
+				delete(attemptsByKey, key)
+			}
+		}
+	}
+}
+
+func (t *loginThrottle) evictPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for now := range ticker.C {
+		t.evict(now)
+	}
+}
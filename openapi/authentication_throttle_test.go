@@ -0,0 +1,101 @@
+package openapi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestLoginThrottle(maxLockout time.Duration) *loginThrottle {
+	return &loginThrottle{
+		maxLockout:  maxLockout,
+		accounts:    map[string]*failedLogins{},
+		clientAddrs: map[string]*failedLogins{},
+	}
+}
+
+func TestLoginThrottleAccountLockout(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		succeeded  bool
+		wantLocked bool
+		wantWait   time.Duration
+	}{
+		{"no failures", 0, false, false, 0},
+		{"free attempts", accountFreeAttempts, false, false, 0},
+		{"first exceeded attempt", accountFreeAttempts + 1, false, true, loginBackoffBase},
+		{"backoff doubles", accountFreeAttempts + 3, false, true, 4 * loginBackoffBase},
+		{"backoff limited to the maximum", accountFreeAttempts + 20, false, true, time.Minute},
+		{"reset by a successful login", accountFreeAttempts + 3, true, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttle := newTestLoginThrottle(time.Minute)
+			for i := 0; i < test.failures; i++ {
+				throttle.Failed("user@example.com", "192.0.2.1")
+			}
+			if test.succeeded {
+				throttle.Succeeded("user@example.com")
+			}
+
+			// a different client is locked out as well, the lockout belongs to the account
+			locked, wait := throttle.Locked("user@example.com", "192.0.2.2")
+			if locked != test.wantLocked {
+				t.Fatalf("Locked() = %v, want %v", locked, test.wantLocked)
+			}
+			if wait > test.wantWait || (test.wantLocked && wait < test.wantWait-time.Second/2) {
+				t.Errorf("Locked() wait = %v, want about %v", wait, test.wantWait)
+			}
+			if locked, _ := throttle.Locked("other@example.com", "192.0.2.2"); locked {
+				t.Errorf("other account is locked")
+			}
+		})
+	}
+}
+
+func TestLoginThrottleClientLockout(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantLocked bool
+	}{
+		{"free attempts", ipFreeAttempts, false},
+		{"exceeded attempts", ipFreeAttempts + 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			throttle := newTestLoginThrottle(time.Minute)
+			// every account stays below its own limit
+			for i := 0; i < test.failures; i++ {
+				throttle.Failed(fmt.Sprintf("user%d@example.com", i), "192.0.2.1")
+			}
+			if locked, _ := throttle.Locked("new@example.com", "192.0.2.1"); locked != test.wantLocked {
+				t.Errorf("Locked() = %v, want %v", locked, test.wantLocked)
+			}
+			if locked, _ := throttle.Locked("new@example.com", "192.0.2.2"); locked {
+				t.Errorf("other client is locked")
+			}
+		})
+	}
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	throttle := newTestLoginThrottle(15 * time.Minute)
+	tests := []struct {
+		exceeded int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{10, 512 * time.Second},
+		{11, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+	for _, test := range tests {
+		if backoff := throttle.backoff(test.exceeded); backoff != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.exceeded, backoff, test.want)
+		}
+	}
+}
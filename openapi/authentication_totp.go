@@ -0,0 +1,158 @@
+package openapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// TOTP parameters as defined in RFC 6238, supported by all common authenticator apps
+const (
+	totpPeriod       = 30
+	totpDigits       = 6
+	totpSecretLength = 20
+	// accept codes of the previous and next time step to compensate clock drift
+	totpSkew = 1
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Generate a new random TOTP secret, base32 encoded as expected by authenticator apps
+func generateTotpSecret() (string, error) {
+	secret := make([]byte, totpSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// The URI encoded in the QR code scanned by authenticator apps
+func totpUri(email string, secret string) string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "GameBase"
+	}
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer + ":" + email)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Calculate the code for the given time step
+func totpCode(secret string, counter int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo), nil
+}
+
+// Check the code against the secret. Returns the time step the code belongs to
+// which has to be newer than lastCounter, so every code can only be used once.
+func verifyTotpCode(secret string, code string, lastCounter int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+		expected, err := totpCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// Generate the recovery codes handed out once to the user and their hashes which are stored
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := []string{}
+	hashes := []string{}
+	random := make([]byte, recoveryCodeLength)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(totpEncoding.EncodeToString(random))[:recoveryCodeLength]
+		code := encoded[:recoveryCodeLength/2] + "-" + encoded[recoveryCodeLength/2:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// Recovery codes are random, a fast hash is sufficient
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify the second factor of a user with 2FA enabled, either a TOTP code or a recovery code.
+// Used codes are recorded so they cannot be used again.
+func verifySecondFactor(ctx context.Context, users UserStore, user *GamebaseUser, code string, recoveryCode string) (bool, error) {
+	if user.TotpSecret == "" {
+		return false, nil
+	}
+
+	if code != "" {
+		counter, valid := verifyTotpCode(user.TotpSecret, code, user.TotpCounter, time.Now())
+		if !valid {
+			return false, nil
+		}
+		return true, users.SetUser(ctx, user.Email, GamebaseUser{TotpCounter: counter})
+	}
+
+	if recoveryCode != "" {
+		hash := hashRecoveryCode(recoveryCode)
+		for i, stored := range user.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) != 1 {
+				continue
+			}
+			remaining := append(append([]string{}, user.RecoveryCodes[:i]...), user.RecoveryCodes[i+1:]...)
+			if len(remaining) == 0 {
+				return true, users.ClearUserFields(ctx, user.Email, "recovery_codes")
+			}
+			return true, users.SetUser(ctx, user.Email, GamebaseUser{RecoveryCodes: remaining})
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,73 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+)
+
+// ASCII secret "12345678901234567890" of the test vectors in RFC 6238, appendix B
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCode(t *testing.T) {
+	// the RFC lists 8 digit codes, the last 6 digits are the 6 digit codes
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, test := range tests {
+		code, err := totpCode(rfc6238Secret, test.unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != test.want {
+			t.Errorf("totpCode() at %d = %s, want %s", test.unix, code, test.want)
+		}
+	}
+}
+
+func TestVerifyTotpCode(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	code := func(counter int64) string {
+		code, err := totpCode(rfc6238Secret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name        string
+		code        string
+		lastCounter int64
+		wantCounter int64
+		wantValid   bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"previous step", code(current - 1), 0, current - 1, true},
+		{"next step", code(current + 1), 0, current + 1, true},
+		{"outside of the window before", code(current - 2), 0, 0, false},
+		{"outside of the window after", code(current + 2), 0, 0, false},
+		{"surrounding whitespace", " " + code(current) + " ", 0, current, true},
+		{"replay of the last code", code(current), current, 0, false},
+		{"code older than the last code", code(current - 1), current, 0, false},
+		{"newer code after the last code", code(current + 1), current, current + 1, true},
+		{"wrong code", "000000", 0, 0, false},
+		{"too short", code(current)[:totpDigits-1], 0, 0, false},
+		{"empty", "", 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter, valid := verifyTotpCode(rfc6238Secret, test.code, test.lastCounter, now)
+			if valid != test.wantValid || counter != test.wantCounter {
+				t.Errorf("verifyTotpCode() = %d, %v, want %d, %v", counter, valid, test.wantCounter, test.wantValid)
+			}
+		})
+	}
+}
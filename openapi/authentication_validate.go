@@ -7,26 +7,88 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"strings"
+	"sync"
 )
 
-func isValidLogin(ctx context.Context, request UserLogin, k kubernetesClient) (bool, error) {
-	user, err := k.GetUserSecret(ctx, request.Email)
-	if err != nil && strings.HasSuffix(err.Error(), "not found") {
-		return false, errors.New("user does not exist")
+// Check the password of the user. Unknown users are rejected the same way as wrong passwords,
+// including the time needed to verify a password, so logins cannot be used to probe for accounts.
+func isValidLogin(ctx context.Context, request UserLogin, users UserStore) (bool, error) {
+	user, err := users.GetUser(ctx, request.Email)
+	if err == errUserNotFound {
+		verifyPassword(dummyPasswordHash(), request.Password)
+		return false, nil
 	}
-
 	if err != nil {
 		return false, err
 	}
 
-	return user.Password == request.Password, nil
+	valid, outdated := verifyPassword(user.Password, request.Password)
+	if valid && outdated {
+		// transparently replace plaintext or outdated password hashes
+		hashedPassword, err := hashPassword(request.Password)
+		if err != nil {
+			return false, err
+		}
+		if err := users.SetUser(ctx, request.Email, GamebaseUser{Password: hashedPassword}); err != nil {
+			return false, err
+		}
+	}
+
+	return valid, nil
+}
+
+var (
+	dummyPasswordHashOnce  sync.Once
+	dummyPasswordHashValue string
+)
+
+// A valid password hash used to verify passwords of unknown users
+func dummyPasswordHash() string {
+	dummyPasswordHashOnce.Do(func() {
+		hash, err := hashPassword("gamebase-unknown-user")
+		if err != nil {
+			panic(err)
+		}
+		dummyPasswordHashValue = hash
+	})
+	return dummyPasswordHashValue
 }
 
 // Extract the authentication header from the request
-// and check the authentication token against the valid authentication tokens
+// and check the authentication token against the valid authentication tokens.
+// The claims of a valid token are stored in the context, see getClaims.
 func isAuthorized(request *gin.Context) bool {
+	claims, err := authenticate(request)
+	if err != nil {
+		return false
+	}
+	request.Set("claims", claims)
+	return true
+}
+
+// Check the access token or personal access token of the request and return its claims
+func authenticate(request *gin.Context) (*userClaims, error) {
+	s := extractJwt(request)
+	if isApiToken(s) {
+		return apiTokens.Verify(request, s)
+	}
+
 	token, err := ParseJwt(request)
-	return err == nil && token.Valid
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("Token invalid!")
+	}
+	return token.Claims.(*userClaims), nil
+}
+
+// Lookup the claims of the token checked by isAuthorized
+func getClaims(request *gin.Context) *userClaims {
+	if claims, exists := request.Get("claims"); exists {
+		return claims.(*userClaims)
+	}
+	return nil
 }
 
 func ParseJwt(request *gin.Context) (*jwt.Token, error) {
@@ -35,18 +97,69 @@ func ParseJwt(request *gin.Context) (*jwt.Token, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	token, err := jwt.ParseWithClaims(s, &userClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
+	token, err := parseToken(request, s)
+	if err != nil {
+		return nil, err
+	}
 
-		return hmacSampleSecret(), nil
-	})
+	// refresh, verification, reset and challenge tokens must only be used for their purpose
+	if claims := token.Claims.(*userClaims); claims.TokenType != accessTokenType {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	return token, nil
+}
+
+// Parse a refresh token and return its claims if it is valid
+func parseRefreshToken(ctx context.Context, s string) (*userClaims, error) {
+	return parseTokenOfType(ctx, s, refreshTokenType)
+}
+
+// Parse an email verification token and return its claims if it is valid
+func parseVerificationToken(ctx context.Context, s string) (*userClaims, error) {
+	return parseTokenOfType(ctx, s, verificationTokenType)
+}
+
+// Parse a password reset token and return its claims if it is valid
+func parseResetToken(ctx context.Context, s string) (*userClaims, error) {
+	return parseTokenOfType(ctx, s, resetTokenType)
+}
+
+// Parse a two-factor challenge token and return its claims if it is valid
+func parseChallengeToken(ctx context.Context, s string) (*userClaims, error) {
+	return parseTokenOfType(ctx, s, challengeTokenType)
+}
+
+func parseTokenOfType(ctx context.Context, s string, tokenType string) (*userClaims, error) {
+	token, err := parseToken(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := token.Claims.(*userClaims)
+	if !token.Valid || claims.TokenType != tokenType || claims.UserEmail == "" {
+		return nil, fmt.Errorf("invalid %s token", tokenType)
+	}
+
+	return claims, nil
+}
+
+// Parse the token, verify its signature and check that it has not been revoked
+func parseToken(ctx context.Context, s string) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(s, &userClaims{}, signingKeys.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	revoked, err := revokedTokens.IsRevoked(ctx, token.Claims.(*userClaims))
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("invalid token")
+	}
+
 	return token, nil
 }
 
@@ -66,17 +179,12 @@ func extractJwt(request *gin.Context) string {
 
 // Lookup the email address from the authentication token
 func extractEmail(request *gin.Context) (string, error) {
-	token, err := ParseJwt(request)
+	if claims := getClaims(request); claims != nil {
+		return claims.UserEmail, nil
+	}
+	claims, err := authenticate(request)
 	if err != nil {
 		return "", err
 	}
-	claims, parsed := token.Claims.(*userClaims)
-	if !parsed {
-		return "", errors.New("Could not parse token!")
-	}
-	if token.Valid {
-		return claims.UserEmail, nil
-	} else {
-		return "", errors.New("Token invalid!")
-	}
+	return claims.UserEmail, nil
 }
@@ -98,6 +98,11 @@ func (gs *gameServer) GetContainerMemoryLimit() int32 {
 	return int32(intLimit) //What could possibly go wrong?
 }
 
+func (gs *gameServer) GetStorageRequest() int64 {
+	storage := gs.pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	return storage.Value()
+}
+
 // SetContainerMemoryLimit - Recreates the PodSpec since Limits are immutable on existing objects
 func (gs *gameServer) SetContainerMemoryLimit(newLimit int32) {
 	if newLimit <= 0 {
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+)
+
+// Collaborators of a game server are stored as labels on its Deployment, one label per user:
+// member.gamebase.gahr.dev/<user uuid>: <role>
+// This allows finding the game servers shared with a user across all namespaces by a label selector.
+const memberLabelPrefix = "member.gamebase.gahr.dev/"
+
+var (
+	errNotShared = errors.New("game server is not shared with the user")
+	errNotMember = errors.New("user is not a member of the game server")
+
+	errInvalidGameServerId = errors.New("invalid game server id")
+)
+
+// Game server ids are the uuids in the deploymentUUID label and end up in label selectors.
+// Only DNS labels are accepted so an id cannot add requirements to a selector.
+func isValidGameServerId(id string) bool {
+	return len(validation.IsDNS1123Label(id)) == 0
+}
+
+// The access of a user to a game server.
+// Every role includes the permissions of the roles below it.
+type memberRole string
+
+const (
+	memberViewer   memberRole = "viewer"   // query the status and the members
+	memberOperator memberRole = "operator" // start, stop and restart
+	memberAdmin    memberRole = "admin"    // configure and share the game server
+	memberOwner    memberRole = "owner"    // the user in whose namespace the game server lives, cannot be granted
+)
+
+func (role memberRole) level() int {
+	switch role {
+	case memberOwner:
+		return 3
+	case memberAdmin:
+		return 2
+	case memberOperator:
+		return 1
+	case memberViewer:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// Check if the role grants the permissions of the required role
+func (role memberRole) includes(required memberRole) bool {
+	return role.level() >= required.level()
+}
+
+func isGrantableMemberRole(role memberRole) bool {
+	return role == memberViewer || role == memberOperator || role == memberAdmin
+}
+
+func memberLabel(uuid string) string {
+	return memberLabelPrefix + uuid
+}
+
+// The members of the game server by user uuid, without the owner
+func (gs *gameServer) GetMembers() map[string]memberRole {
+	members := map[string]memberRole{}
+	for key, value := range gs.deployment.Labels {
+		if strings.HasPrefix(key, memberLabelPrefix) {
+			members[strings.TrimPrefix(key, memberLabelPrefix)] = memberRole(value)
+		}
+	}
+	return members
+}
+
+// Lookup the access to the namespace or game server resolved by extractNamespace or extractGameServerNamespace
+func getMemberRole(c *gin.Context) memberRole {
+	if role, exists := c.Get("memberRole"); exists {
+		return role.(memberRole)
+	}
+	return ""
+}
+
+// construct the GameServerMember returned by the member endpoints
+func (user GamebaseUser) toGameServerMember(role memberRole) GameServerMember {
+	return GameServerMember{
+		Email:    user.Email,
+		FullName: user.Name,
+		Role:     string(role),
+	}
+}
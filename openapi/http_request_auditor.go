@@ -0,0 +1,402 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	uuidGen "github.com/twinj/uuid"
+	"net/http"
+	"time"
+)
+
+// maximum size of an error response kept to extract the error message
+const maxAuditedResponseSize = 4096
+
+// First handler of the chain. Records the actions which change users or game servers
+// together with their outcome in the audit log once the rest of the chain has handled them.
+type httpRequestAuditor struct {
+	nextHandler httpRequestHandler
+}
+
+func newHttpRequestAuditor() *httpRequestAuditor {
+	hr := &httpRequestAuditor{nextHandler: newHttpRequestAuthenticator()}
+	auditLog = newAuditSink(hr.kubernetesClient())
+	return hr
+}
+
+func (hr *httpRequestAuditor) kubernetesClient() kubernetesClient {
+	return hr.nextHandler.kubernetesClient()
+}
+
+func (hr *httpRequestAuditor) userStore() UserStore {
+	return hr.nextHandler.userStore()
+}
+
+// Captures the status and the body of error responses
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxAuditedResponseSize {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Pass the request to the next handler and record the action in the audit log
+func (hr *httpRequestAuditor) audit(c *gin.Context, action string, handle func(c *gin.Context)) {
+	writer := &auditResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	handle(c)
+	c.Writer = writer.ResponseWriter
+
+	event := AuditEvent{
+		Id:        uuidGen.NewV4().String(),
+		Time:      time.Now().UTC(),
+		ClientIp:  c.ClientIP(),
+		Action:    action,
+		Target:    c.GetString("id"),
+		Namespace: c.GetString("namespace"),
+		Outcome:   auditOutcomeSuccess,
+		Status:    writer.Status(),
+	}
+
+	// the actor is the authenticated user, otherwise the user the unauthenticated request refers to
+	if claims := getClaims(c); claims != nil {
+		event.Actor = claims.UserEmail
+		event.TokenType = claims.TokenType
+		if event.Target == "" {
+			// the user managed by an admin or the member of a game server or organization
+			event.Target = c.GetString("targetEmail")
+		}
+	} else {
+		event.Actor = c.GetString("email")
+	}
+
+	if request, exists := c.Get("request"); exists {
+		event.Request = auditValue(request)
+		if event.Actor == "" {
+			switch typed := request.(type) {
+			case GamebaseUser:
+				event.Actor = typed.Email
+			case PasswordForgot:
+				event.Actor = typed.Email
+			}
+		}
+	}
+
+	before, hasBefore := c.Get("auditBefore")
+	after, hasAfter := c.Get("auditAfter")
+	if hasBefore || hasAfter {
+		event.Changes = auditChanges(before, after)
+	}
+
+	if event.Status >= http.StatusBadRequest {
+		event.Outcome = auditOutcomeFailure
+		event.Error = auditErrorMessage(writer.body.Bytes())
+	}
+
+	if err := auditLog.Record(context.Background(), event); err != nil {
+		fmt.Println("Could not record audit event " + action + ": " + err.Error())
+	}
+}
+
+// Extract the message of an error response, which is either {"error": ...} or an Exception
+func auditErrorMessage(body []byte) string {
+	var response struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return ""
+	}
+	if response.Error != "" {
+		return response.Error
+	}
+	return response.Details
+}
+
+// Login - Login a user and return a JWT with the user object
+func (hr *httpRequestAuditor) Login(c *gin.Context) {
+	hr.audit(c, "Login", hr.nextHandler.Login)
+}
+
+// Logout - Invalidate the passed JWT
+func (hr *httpRequestAuditor) Logout(c *gin.Context) {
+	hr.audit(c, "Logout", hr.nextHandler.Logout)
+}
+
+// Refresh - Exchange a refresh token for a new pair of tokens
+func (hr *httpRequestAuditor) Refresh(c *gin.Context) {
+	//refreshing tokens is not audited, the login is
+	hr.nextHandler.Refresh(c)
+}
+
+// VerifyTwoFactor - Exchange a two-factor challenge token and code for a JWT with the user object
+func (hr *httpRequestAuditor) VerifyTwoFactor(c *gin.Context) {
+	hr.audit(c, "VerifyTwoFactor", hr.nextHandler.VerifyTwoFactor)
+}
+
+// Jwks - Get the public keys used to sign the JWTs
+func (hr *httpRequestAuditor) Jwks(c *gin.Context) {
+	//read only
+	hr.nextHandler.Jwks(c)
+}
+
+// Register - Register a user and return a JWT with the user object
+func (hr *httpRequestAuditor) Register(c *gin.Context) {
+	hr.audit(c, "Register", hr.nextHandler.Register)
+}
+
+// Verify - Verify the email address of a new user and return a JWT with the user object
+func (hr *httpRequestAuditor) Verify(c *gin.Context) {
+	hr.audit(c, "Verify", hr.nextHandler.Verify)
+}
+
+// ForgotPassword - Send a link to reset the password to the user
+func (hr *httpRequestAuditor) ForgotPassword(c *gin.Context) {
+	hr.audit(c, "ForgotPassword", hr.nextHandler.ForgotPassword)
+}
+
+// ResetPassword - Set a new password using the token sent by ForgotPassword
+func (hr *httpRequestAuditor) ResetPassword(c *gin.Context) {
+	hr.audit(c, "ResetPassword", hr.nextHandler.ResetPassword)
+}
+
+// OidcLogin - Redirect to the OIDC identity provider to log in
+func (hr *httpRequestAuditor) OidcLogin(c *gin.Context) {
+	//the login is audited on the callback
+	hr.nextHandler.OidcLogin(c)
+}
+
+// OidcCallback - Finish the login at the OIDC identity provider and return a JWT with the user object
+func (hr *httpRequestAuditor) OidcCallback(c *gin.Context) {
+	hr.audit(c, "OidcCallback", hr.nextHandler.OidcCallback)
+}
+
+// ListTemplates - Get a list of all available game server images
+func (hr *httpRequestAuditor) ListTemplates(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListTemplates(c)
+}
+
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
+func (hr *httpRequestAuditor) GetStatus(c *gin.Context) {
+	//read only
+	hr.nextHandler.GetStatus(c)
+}
+
+// ConfigureContainer - Configure a game server based on POST body
+func (hr *httpRequestAuditor) ConfigureContainer(c *gin.Context) {
+	hr.audit(c, "ConfigureContainer", hr.nextHandler.ConfigureContainer)
+}
+
+// DeployContainer - Deploy a game server based on POST body
+func (hr *httpRequestAuditor) DeployContainer(c *gin.Context) {
+	hr.audit(c, "DeployContainer", hr.nextHandler.DeployContainer)
+}
+
+// StartContainer - Start a game server/container
+func (hr *httpRequestAuditor) StartContainer(c *gin.Context) {
+	hr.audit(c, "StartContainer", hr.nextHandler.StartContainer)
+}
+
+// StopContainer - Stop a game server/container
+func (hr *httpRequestAuditor) StopContainer(c *gin.Context) {
+	hr.audit(c, "StopContainer", hr.nextHandler.StopContainer)
+}
+
+// RestartContainer - Restart a game server/container
+func (hr *httpRequestAuditor) RestartContainer(c *gin.Context) {
+	hr.audit(c, "RestartContainer", hr.nextHandler.RestartContainer)
+}
+
+// DeleteContainer - Delete deployment of game server
+func (hr *httpRequestAuditor) DeleteContainer(c *gin.Context) {
+	hr.audit(c, "DeleteContainer", hr.nextHandler.DeleteContainer)
+}
+
+// ListGameServerMembers - List the users a game server is shared with
+func (hr *httpRequestAuditor) ListGameServerMembers(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListGameServerMembers(c)
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func (hr *httpRequestAuditor) SetGameServerMember(c *gin.Context) {
+	hr.audit(c, "SetGameServerMember", hr.nextHandler.SetGameServerMember)
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func (hr *httpRequestAuditor) RemoveGameServerMember(c *gin.Context) {
+	hr.audit(c, "RemoveGameServerMember", hr.nextHandler.RemoveGameServerMember)
+}
+
+// UpdateUserProfile - Update fields of a user's profile
+func (hr *httpRequestAuditor) UpdateUserProfile(c *gin.Context) {
+	hr.audit(c, "UpdateUserProfile", hr.nextHandler.UpdateUserProfile)
+}
+
+// GetUserProfile - Get the profile of the authenticated user
+func (hr *httpRequestAuditor) GetUserProfile(c *gin.Context) {
+	//read only
+	hr.nextHandler.GetUserProfile(c)
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func (hr *httpRequestAuditor) EnrollTwoFactor(c *gin.Context) {
+	hr.audit(c, "EnrollTwoFactor", hr.nextHandler.EnrollTwoFactor)
+}
+
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func (hr *httpRequestAuditor) ConfirmTwoFactor(c *gin.Context) {
+	hr.audit(c, "ConfirmTwoFactor", hr.nextHandler.ConfirmTwoFactor)
+}
+
+// DisableTwoFactor - Disable two-factor authentication
+func (hr *httpRequestAuditor) DisableTwoFactor(c *gin.Context) {
+	hr.audit(c, "DisableTwoFactor", hr.nextHandler.DisableTwoFactor)
+}
+
+// DeleteUser - Delete the account of the user with all game servers
+func (hr *httpRequestAuditor) DeleteUser(c *gin.Context) {
+	hr.audit(c, "DeleteUser", hr.nextHandler.DeleteUser)
+}
+
+// GetAccountDeletion - Query the progress of an account deletion
+func (hr *httpRequestAuditor) GetAccountDeletion(c *gin.Context) {
+	//read only
+	hr.nextHandler.GetAccountDeletion(c)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func (hr *httpRequestAuditor) ListApiTokens(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListApiTokens(c)
+}
+
+// CreateApiToken - Create a personal access token for scripts and automation
+func (hr *httpRequestAuditor) CreateApiToken(c *gin.Context) {
+	hr.audit(c, "CreateApiToken", hr.nextHandler.CreateApiToken)
+}
+
+// RevokeApiToken - Revoke a personal access token
+func (hr *httpRequestAuditor) RevokeApiToken(c *gin.Context) {
+	hr.audit(c, "RevokeApiToken", hr.nextHandler.RevokeApiToken)
+}
+
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func (hr *httpRequestAuditor) ListInvitations(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListInvitations(c)
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func (hr *httpRequestAuditor) CreateInvitation(c *gin.Context) {
+	hr.audit(c, "CreateInvitation", hr.nextHandler.CreateInvitation)
+}
+
+// DeleteInvitation - Delete an invitation code
+func (hr *httpRequestAuditor) DeleteInvitation(c *gin.Context) {
+	hr.audit(c, "DeleteInvitation", hr.nextHandler.DeleteInvitation)
+}
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func (hr *httpRequestAuditor) ListAuditEvents(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListAuditEvents(c)
+}
+
+// ListOrganizations - List the organizations of the user
+func (hr *httpRequestAuditor) ListOrganizations(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListOrganizations(c)
+}
+
+// CreateOrganization - Create an organization owned by the user
+func (hr *httpRequestAuditor) CreateOrganization(c *gin.Context) {
+	hr.audit(c, "CreateOrganization", hr.nextHandler.CreateOrganization)
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func (hr *httpRequestAuditor) DeleteOrganization(c *gin.Context) {
+	hr.audit(c, "DeleteOrganization", hr.nextHandler.DeleteOrganization)
+}
+
+// ListOrganizationMembers - List the members of an organization
+func (hr *httpRequestAuditor) ListOrganizationMembers(c *gin.Context) {
+	//read only
+	hr.nextHandler.ListOrganizationMembers(c)
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func (hr *httpRequestAuditor) SetOrganizationMember(c *gin.Context) {
+	hr.audit(c, "SetOrganizationMember", hr.nextHandler.SetOrganizationMember)
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func (hr *httpRequestAuditor) RemoveOrganizationMember(c *gin.Context) {
+	hr.audit(c, "RemoveOrganizationMember", hr.nextHandler.RemoveOrganizationMember)
+}
+
+// AdminListUsers - List all registered users
+func (hr *httpRequestAuditor) AdminListUsers(c *gin.Context) {
+	//read only
+	hr.nextHandler.AdminListUsers(c)
+}
+
+// AdminListGameServers - List the game servers of all users
+func (hr *httpRequestAuditor) AdminListGameServers(c *gin.Context) {
+	//read only
+	hr.nextHandler.AdminListGameServers(c)
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func (hr *httpRequestAuditor) AdminListOrphanedGameServers(c *gin.Context) {
+	//read only
+	hr.nextHandler.AdminListOrphanedGameServers(c)
+}
+
+// AdminGetUser - Get a registered user
+func (hr *httpRequestAuditor) AdminGetUser(c *gin.Context) {
+	//read only
+	hr.nextHandler.AdminGetUser(c)
+}
+
+// AdminSetUserRole - Change the role of a user
+func (hr *httpRequestAuditor) AdminSetUserRole(c *gin.Context) {
+	hr.audit(c, "AdminSetUserRole", hr.nextHandler.AdminSetUserRole)
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func (hr *httpRequestAuditor) AdminDisableUser(c *gin.Context) {
+	hr.audit(c, "AdminDisableUser", hr.nextHandler.AdminDisableUser)
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func (hr *httpRequestAuditor) AdminEnableUser(c *gin.Context) {
+	hr.audit(c, "AdminEnableUser", hr.nextHandler.AdminEnableUser)
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func (hr *httpRequestAuditor) AdminResetUserPassword(c *gin.Context) {
+	hr.audit(c, "AdminResetUserPassword", hr.nextHandler.AdminResetUserPassword)
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func (hr *httpRequestAuditor) AdminDeleteUser(c *gin.Context) {
+	hr.audit(c, "AdminDeleteUser", hr.nextHandler.AdminDeleteUser)
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func (hr *httpRequestAuditor) AdminUnlockUser(c *gin.Context) {
+	hr.audit(c, "AdminUnlockUser", hr.nextHandler.AdminUnlockUser)
+}
@@ -2,7 +2,11 @@ package openapi
 
 import (
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type httpRequestAuthenticator struct {
@@ -10,16 +14,31 @@ type httpRequestAuthenticator struct {
 }
 
 func newHttpRequestAuthenticator() *httpRequestAuthenticator {
-	return &httpRequestAuthenticator{nextHandler: newHttpRequestParser()}
+	hr := &httpRequestAuthenticator{nextHandler: newHttpRequestRoleAuthorizer()}
+	revokedTokens = newTokenRevocationStore(hr.kubernetesClient())
+	signingKeys = newJwtKeyManager(hr.kubernetesClient())
+	mails = newMailer()
+	loginAttempts = newLoginThrottle()
+	oidc = newOidcProvider()
+	apiTokens = newApiTokenStore(hr.userStore())
+	registration = newRegistrationMode()
+	invitationRole = newInvitationRole()
+	invitations = newInvitationStore(hr.kubernetesClient())
+	organizations = newOrganizationStore(hr.kubernetesClient())
+	return hr
 }
 
 func (hr *httpRequestAuthenticator) kubernetesClient() kubernetesClient {
 	return hr.nextHandler.kubernetesClient()
 }
 
+func (hr *httpRequestAuthenticator) userStore() UserStore {
+	return hr.nextHandler.userStore()
+}
+
 // Login - Login a user and return a JWT with the user object
 func (hr *httpRequestAuthenticator) Login(c *gin.Context) {
-	k := hr.kubernetesClient()
+	users := hr.userStore()
 
 	var request UserLogin
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -27,44 +46,200 @@ func (hr *httpRequestAuthenticator) Login(c *gin.Context) {
 		return
 	}
 
-	validLogin, err := isValidLogin(c, request, k)
+	request.Email = normalizeEmail(request.Email)
+	c.Set("email", request.Email)
+	if locked, wait := loginAttempts.Locked(request.Email, c.ClientIP()); locked {
+		respondLoginLocked(c, wait)
+		return
+	}
+
+	validLogin, err := isValidLogin(c, request, users)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	if !validLogin {
+		loginAttempts.Failed(request.Email, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
 		return
 	}
 
-	user, err := k.GetUserSecret(c, request.Email)
+	user, err := users.GetUser(c, request.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, _, err := createToken(*user)
+	if denial := user.Status.loginDenial(); denial != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": denial})
+		return
+	}
+
+	// users with two-factor authentication get a token pair only after verifying the code,
+	// the failed attempts are reset once the second factor has been verified as well
+	if user.TotpSecret != "" {
+		challengeToken, err := createChallengeToken(*user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, TwoFactorChallenge{ChallengeToken: challengeToken})
+		return
+	}
+	loginAttempts.Succeeded(request.Email)
+
+	token, refreshToken, err := createToken(*user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, User{
-		Email:    user.Email,
-		FullName: user.Name,
-		Token:    token,
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
 	})
 }
 
 // Logout - Invalidate the passed JWT
+// and the refresh token issued with it. If the query parameter all is true
+// every token of the user is invalidated (log out all sessions).
 func (hr *httpRequestAuthenticator) Logout(c *gin.Context) {
-	if email, _ := extractEmail(c); email != "" {
+	token, err := ParseJwt(c)
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	claims := token.Claims.(*userClaims)
+	c.Set("claims", claims)
+
+	if c.Query("all") == "true" {
+		if err := revokeAllTokens(c, claims.UserEmail); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{"success": "success"})
 		return
 	}
 
-	c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+	if err := revokeTokenPair(c, claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": "success"})
+}
+
+// Refresh - Exchange a refresh token for a new pair of tokens
+func (hr *httpRequestAuthenticator) Refresh(c *gin.Context) {
+	users := hr.userStore()
+
+	var request TokenRefresh
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := parseRefreshToken(c, request.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	// every refresh token may only be used once (refresh token rotation)
+	if err := revokedTokens.Revoke(c, claims.TokenUuid, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the user might have been changed or deleted since the refresh token was issued
+	user, err := users.GetUser(c, claims.UserEmail)
+	if err != nil || user.Status.loginDenial() != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	// refreshing does not authenticate the user again
+	token, refreshToken, err := createSessionToken(*user, claims.AuthTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, User{
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// VerifyTwoFactor - Exchange a two-factor challenge token and code for a JWT with the user object
+func (hr *httpRequestAuthenticator) VerifyTwoFactor(c *gin.Context) {
+	users := hr.userStore()
+
+	var request TwoFactorLogin
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := parseChallengeToken(c, request.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge token"})
+		return
+	}
+
+	c.Set("email", claims.UserEmail)
+	if locked, wait := loginAttempts.Locked(claims.UserEmail, c.ClientIP()); locked {
+		respondLoginLocked(c, wait)
+		return
+	}
+
+	// every challenge allows a single attempt, after a wrong code the user has to log in again
+	if err := revokedTokens.Revoke(c, claims.TokenUuid, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := users.GetUser(c, claims.UserEmail)
+	if err != nil || user.Status.loginDenial() != "" || claims.PasswordFingerprint != passwordFingerprint(user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired challenge token"})
+		return
+	}
+
+	valid, err := verifySecondFactor(c, users, user, request.Code, request.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !valid {
+		// wrong codes count against the same lockout as wrong passwords
+		loginAttempts.Failed(claims.UserEmail, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+		return
+	}
+	loginAttempts.Succeeded(claims.UserEmail)
+
+	token, refreshToken, err := createToken(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, User{
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// Jwks - Get the public keys used to sign the JWTs
+func (hr *httpRequestAuthenticator) Jwks(c *gin.Context) {
+	c.JSON(http.StatusOK, signingKeys.jwks())
 }
 
 // Register - Register a user and return a JWT with the user object
@@ -72,27 +247,139 @@ func (hr *httpRequestAuthenticator) Register(c *gin.Context) {
 	hr.nextHandler.Register(c)
 }
 
+// Verify - Verify the email address of a new user and return a JWT with the user object
+func (hr *httpRequestAuthenticator) Verify(c *gin.Context) {
+	claims, err := parseVerificationToken(c, c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+	c.Set("email", claims.UserEmail)
+
+	// the link is invalid once the password has been changed, e.g. by someone registering the pending address again
+	user, err := hr.userStore().GetUser(c, claims.UserEmail)
+	if err != nil && err != errUserNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err == errUserNotFound || claims.PasswordFingerprint != passwordFingerprint(user.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+
+	if claims.NewEmail != "" {
+		// the link of an email change can only be used once
+		if err := revokedTokens.Revoke(c, claims.TokenUuid, time.Unix(claims.ExpiresAt, 0)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set("newEmail", claims.NewEmail)
+	}
+	hr.nextHandler.Verify(c)
+}
+
+// ForgotPassword - Send a link to reset the password to the user
+func (hr *httpRequestAuthenticator) ForgotPassword(c *gin.Context) {
+	hr.nextHandler.ForgotPassword(c)
+}
+
+// ResetPassword - Set a new password using the token sent by ForgotPassword
+func (hr *httpRequestAuthenticator) ResetPassword(c *gin.Context) {
+	var request PasswordReset
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := parseResetToken(c, request.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+		return
+	}
+	c.Set("claims", claims)
+	c.Set("request", request)
+	hr.nextHandler.ResetPassword(c)
+}
+
+// OidcLogin - Redirect to the OIDC identity provider to log in
+func (hr *httpRequestAuthenticator) OidcLogin(c *gin.Context) {
+	if oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	location, stateToken, err := oidc.startLogin(c)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	setOidcStateCookie(c, stateToken, int(oidcStateTokenDuration.Seconds()))
+	c.Redirect(http.StatusFound, location)
+}
+
+// OidcCallback - Finish the login at the OIDC identity provider and return a JWT with the user object
+func (hr *httpRequestAuthenticator) OidcCallback(c *gin.Context) {
+	if oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC login is not configured"})
+		return
+	}
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login at the identity provider failed: " + providerError + " " + c.Query("error_description")})
+		return
+	}
+
+	stateToken, err := c.Cookie(oidcStateCookie)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired login state"})
+		return
+	}
+	// every login state can only be used once
+	setOidcStateCookie(c, "", -1)
+
+	identity, err := oidc.finishLogin(c, stateToken, c.Query("state"), c.Query("code"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set("identity", *identity)
+	c.Set("email", identity.Email)
+	hr.nextHandler.OidcCallback(c)
+}
+
+func setOidcStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcCookiePath, "", strings.HasPrefix(oidc.redirectUrl, "https://"), true)
+}
+
 // ListTemplates - Get a list of all available game server images
 func (hr *httpRequestAuthenticator) ListTemplates(c *gin.Context) {
 	if !isAuthorized(c) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.ListTemplates(c)
 }
 
-// GetStatus - Query status of all deployments
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
 func (hr *httpRequestAuthenticator) GetStatus(c *gin.Context) {
 	if !isAuthorized(c) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var err error
+	if c.Param("id") != "" {
+		// a single game server, which may be shared with the user
+		err = extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore())
+	} else {
+		err = extractNamespace(c, hr.userStore())
+	}
+	if err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.GetStatus(c)
@@ -104,8 +391,8 @@ func (hr *httpRequestAuthenticator) ConfigureContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.ConfigureContainer(c)
@@ -117,8 +404,8 @@ func (hr *httpRequestAuthenticator) DeployContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.DeployContainer(c)
@@ -130,8 +417,8 @@ func (hr *httpRequestAuthenticator) StartContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.StartContainer(c)
@@ -143,8 +430,8 @@ func (hr *httpRequestAuthenticator) StopContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.StopContainer(c)
@@ -156,8 +443,8 @@ func (hr *httpRequestAuthenticator) RestartContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.RestartContainer(c)
@@ -169,81 +456,425 @@ func (hr *httpRequestAuthenticator) DeleteContainer(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if err := extractNamespace(c, hr.kubernetesClient()); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
 		return
 	}
 	hr.nextHandler.DeleteContainer(c)
 }
 
+// ListGameServerMembers - List the users a game server is shared with
+func (hr *httpRequestAuthenticator) ListGameServerMembers(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.ListGameServerMembers(c)
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func (hr *httpRequestAuthenticator) SetGameServerMember(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.SetGameServerMember(c)
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func (hr *httpRequestAuthenticator) RemoveGameServerMember(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractGameServerNamespace(c, hr.kubernetesClient(), hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.RemoveGameServerMember(c)
+}
+
 func (hr *httpRequestAuthenticator) AuthLoginPost(c *gin.Context) {
-	k := hr.nextHandler.kubernetesClient()
+	hr.Login(c)
+}
 
-	var request UserLogin
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (hr *httpRequestAuthenticator) UpdateUserProfile(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
 
-	valid, err := isValidLogin(c, request, k)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	hr.nextHandler.UpdateUserProfile(c)
+}
+
+// GetUserProfile - Get the profile of the authenticated user
+func (hr *httpRequestAuthenticator) GetUserProfile(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
-	if !valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.GetUserProfile(c)
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func (hr *httpRequestAuthenticator) EnrollTwoFactor(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
+	hr.nextHandler.EnrollTwoFactor(c)
+}
 
-	user, err := k.GetUserSecret(c, request.Email)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func (hr *httpRequestAuthenticator) ConfirmTwoFactor(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
+	hr.nextHandler.ConfirmTwoFactor(c)
+}
 
-	token, _, err := createToken(*user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// DisableTwoFactor - Disable two-factor authentication
+func (hr *httpRequestAuthenticator) DisableTwoFactor(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
+	hr.nextHandler.DisableTwoFactor(c)
+}
 
-	c.JSON(http.StatusOK, User{
-		Email:    user.Email,
-		FullName: user.Name,
-		Token:    token,
-	})
+// DeleteUser - Delete the account of the user with all game servers
+func (hr *httpRequestAuthenticator) DeleteUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.DeleteUser(c)
 }
 
-func (hr *httpRequestAuthenticator) AuthLogoutDelete(c *gin.Context) {
-	if email, _ := extractEmail(c); email != "" {
-		c.JSON(http.StatusOK, gin.H{"success": "success"})
+// GetAccountDeletion - Query the progress of an account deletion
+func (hr *httpRequestAuthenticator) GetAccountDeletion(c *gin.Context) {
+	// the tokens of the user are revoked by the deletion, the random deletion id is sufficient
+	hr.nextHandler.GetAccountDeletion(c)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func (hr *httpRequestAuthenticator) ListApiTokens(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
+	hr.nextHandler.ListApiTokens(c)
+}
 
-	c.JSON(http.StatusOK, gin.H{"error": "invalid authentication token"})
+// CreateApiToken - Create a personal access token for scripts and automation
+func (hr *httpRequestAuthenticator) CreateApiToken(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.CreateApiToken(c)
 }
 
-func (hr *httpRequestAuthenticator) UpdateUserProfile(c *gin.Context) {
+// RevokeApiToken - Revoke a personal access token
+func (hr *httpRequestAuthenticator) RevokeApiToken(c *gin.Context) {
 	if !isAuthorized(c) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
 		return
 	}
+	hr.nextHandler.RevokeApiToken(c)
+}
 
-	hr.nextHandler.UpdateUserProfile(c)
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func (hr *httpRequestAuthenticator) ListInvitations(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.ListInvitations(c)
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func (hr *httpRequestAuthenticator) CreateInvitation(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.CreateInvitation(c)
+}
+
+// DeleteInvitation - Delete an invitation code
+func (hr *httpRequestAuthenticator) DeleteInvitation(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.DeleteInvitation(c)
+}
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func (hr *httpRequestAuthenticator) ListAuditEvents(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.ListAuditEvents(c)
+}
+
+// ListOrganizations - List the organizations of the user
+func (hr *httpRequestAuthenticator) ListOrganizations(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.ListOrganizations(c)
+}
+
+// CreateOrganization - Create an organization owned by the user
+func (hr *httpRequestAuthenticator) CreateOrganization(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.CreateOrganization(c)
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func (hr *httpRequestAuthenticator) DeleteOrganization(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.DeleteOrganization(c)
+}
+
+// ListOrganizationMembers - List the members of an organization
+func (hr *httpRequestAuthenticator) ListOrganizationMembers(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.ListOrganizationMembers(c)
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func (hr *httpRequestAuthenticator) SetOrganizationMember(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.SetOrganizationMember(c)
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func (hr *httpRequestAuthenticator) RemoveOrganizationMember(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	if err := extractNamespace(c, hr.userStore()); err != nil {
+		respondNamespaceError(c, err)
+		return
+	}
+	hr.nextHandler.RemoveOrganizationMember(c)
+}
+
+// AdminListUsers - List all registered users
+func (hr *httpRequestAuthenticator) AdminListUsers(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminListUsers(c)
+}
+
+// AdminListGameServers - List the game servers of all users
+func (hr *httpRequestAuthenticator) AdminListGameServers(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminListGameServers(c)
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func (hr *httpRequestAuthenticator) AdminListOrphanedGameServers(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminListOrphanedGameServers(c)
+}
+
+// AdminGetUser - Get a registered user
+func (hr *httpRequestAuthenticator) AdminGetUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminGetUser(c)
+}
+
+// AdminSetUserRole - Change the role of a user
+func (hr *httpRequestAuthenticator) AdminSetUserRole(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminSetUserRole(c)
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func (hr *httpRequestAuthenticator) AdminDisableUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminDisableUser(c)
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func (hr *httpRequestAuthenticator) AdminEnableUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminEnableUser(c)
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func (hr *httpRequestAuthenticator) AdminResetUserPassword(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminResetUserPassword(c)
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func (hr *httpRequestAuthenticator) AdminDeleteUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminDeleteUser(c)
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func (hr *httpRequestAuthenticator) AdminUnlockUser(c *gin.Context) {
+	if !isAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return
+	}
+	hr.nextHandler.AdminUnlockUser(c)
 }
 
-// Sets the target namespace based on the Request JWT
-func extractNamespace(c *gin.Context, k kubernetesClient) error {
+// Answer logins while the account or client is locked out after too many failed attempts
+func respondLoginLocked(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed login attempts, try again later"})
+}
+
+// Sets the target namespace based on the Request JWT, or the namespace of the organization
+// selected by the path segment /orgs/:org or the organization header.
+// The access of the user to the namespace is stored as memberRole.
+func extractNamespace(c *gin.Context, users UserStore) error {
 	email, err := extractEmail(c)
 	if err != nil {
 		return err
 	}
 
-	uuid, err := k.GetUuid(c, email)
+	uuid, err := users.GetUuid(c, email)
 	if err != nil {
 		return err
 	}
 
+	if id := organizationId(c); id != "" {
+		org, err := organizations.Get(c, id)
+		if err == errOrganizationNotFound {
+			// do not reveal which organizations exist
+			return errNotOrganizationMember
+		}
+		if err != nil {
+			return err
+		}
+		role, isMember := org.Members[uuid]
+		if !isMember {
+			return errNotOrganizationMember
+		}
+		c.Set("organization", org.Id)
+		c.Set("namespace", org.namespace())
+		c.Set("memberRole", role)
+		return nil
+	}
+
 	c.Set("namespace", defaultNamespaceUser+uuid)
+	c.Set("memberRole", memberOwner)
+	return nil
+}
+
+// Respond to a failed extractNamespace or extractGameServerNamespace
+func respondNamespaceError(c *gin.Context, err error) {
+	if err == errInvalidGameServerId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == errNotOrganizationMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// Resolve the namespace of the game server in the URL and the access of the user to it.
+// Game servers of other users are found through the collaborator grant of the user.
+func extractGameServerNamespace(c *gin.Context, cl kubernetesClient, users UserStore) error {
+	// the parser comes after the authenticator, the id is checked before it is used for the lookup
+	if !isValidGameServerId(c.Param("id")) {
+		return errInvalidGameServerId
+	}
+	if err := extractNamespace(c, users); err != nil {
+		return err
+	}
+	if c.GetString("organization") != "" {
+		// the members of an organization access its game servers through their membership
+		return nil
+	}
+
+	uuid := strings.TrimPrefix(c.GetString("namespace"), defaultNamespaceUser)
+	namespace, role, err := cl.FindSharedGameServer(c, c.Param("id"), uuid)
+	if err == errNotShared {
+		// the own game server, or it does not exist which is reported by the controller
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.Set("namespace", namespace)
+	c.Set("memberRole", role)
 	return nil
 }
@@ -6,9 +6,18 @@ import (
 
 type httpRequestHandler interface {
 	kubernetesClient() kubernetesClient
+	userStore() UserStore
 	Login(c *gin.Context)
 	Logout(c *gin.Context)
+	Refresh(c *gin.Context)
+	VerifyTwoFactor(c *gin.Context)
+	Jwks(c *gin.Context)
 	Register(c *gin.Context)
+	Verify(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	OidcLogin(c *gin.Context)
+	OidcCallback(c *gin.Context)
 	ListTemplates(c *gin.Context)
 	GetStatus(c *gin.Context)
 	ConfigureContainer(c *gin.Context)
@@ -17,5 +26,37 @@ type httpRequestHandler interface {
 	StopContainer(c *gin.Context)
 	RestartContainer(c *gin.Context)
 	DeleteContainer(c *gin.Context)
+	ListGameServerMembers(c *gin.Context)
+	SetGameServerMember(c *gin.Context)
+	RemoveGameServerMember(c *gin.Context)
 	UpdateUserProfile(c *gin.Context)
+	GetUserProfile(c *gin.Context)
+	EnrollTwoFactor(c *gin.Context)
+	ConfirmTwoFactor(c *gin.Context)
+	DisableTwoFactor(c *gin.Context)
+	DeleteUser(c *gin.Context)
+	GetAccountDeletion(c *gin.Context)
+	ListApiTokens(c *gin.Context)
+	CreateApiToken(c *gin.Context)
+	RevokeApiToken(c *gin.Context)
+	ListInvitations(c *gin.Context)
+	CreateInvitation(c *gin.Context)
+	DeleteInvitation(c *gin.Context)
+	ListAuditEvents(c *gin.Context)
+	ListOrganizations(c *gin.Context)
+	CreateOrganization(c *gin.Context)
+	DeleteOrganization(c *gin.Context)
+	ListOrganizationMembers(c *gin.Context)
+	SetOrganizationMember(c *gin.Context)
+	RemoveOrganizationMember(c *gin.Context)
+	AdminListUsers(c *gin.Context)
+	AdminListGameServers(c *gin.Context)
+	AdminListOrphanedGameServers(c *gin.Context)
+	AdminGetUser(c *gin.Context)
+	AdminSetUserRole(c *gin.Context)
+	AdminDisableUser(c *gin.Context)
+	AdminEnableUser(c *gin.Context)
+	AdminResetUserPassword(c *gin.Context)
+	AdminDeleteUser(c *gin.Context)
+	AdminUnlockUser(c *gin.Context)
 }
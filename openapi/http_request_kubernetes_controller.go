@@ -1,25 +1,43 @@
 package openapi
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
 type httpRequestKubernetesController struct {
 	nextHandler httpRequestHandler
 	cl          kubernetesClient
+	users       UserStore
 	templates   []*gameServerTemplate
+	orphans     *orphanSweeper
 }
 
 func newHttpRequestKubernetesController() *httpRequestKubernetesController {
-	return &httpRequestKubernetesController{cl: newKubernetesClientset(), templates: readGameServerTemplates()}
+	cl := newKubernetesClientset()
+	templates := readGameServerTemplates()
+	users := newUserStore(cl)
+	if err := promoteConfiguredAdmins(context.Background(), users); err != nil {
+		fmt.Println("Could not promote the users listed in ADMIN_EMAILS: " + err.Error())
+	}
+	return &httpRequestKubernetesController{cl: cl, users: users, templates: templates, orphans: newOrphanSweeper(cl, templates)}
 }
 
 func (hr *httpRequestKubernetesController) kubernetesClient() kubernetesClient {
 	return hr.cl
 }
 
+func (hr *httpRequestKubernetesController) userStore() UserStore {
+	return hr.users
+}
+
 // Login - Login a user and return a JWT with the user object
 func (hr *httpRequestKubernetesController) Login(c *gin.Context) {
 	return
@@ -30,7 +48,22 @@ func (hr *httpRequestKubernetesController) Logout(c *gin.Context) {
 	return
 }
 
-// Register - Register a user and return a JWT with the user object
+// Refresh - Exchange a refresh token for a new pair of tokens
+func (hr *httpRequestKubernetesController) Refresh(c *gin.Context) {
+	return
+}
+
+// VerifyTwoFactor - Exchange a two-factor challenge token and code for a JWT with the user object
+func (hr *httpRequestKubernetesController) VerifyTwoFactor(c *gin.Context) {
+	return
+}
+
+// Jwks - Get the public keys used to sign the JWTs
+func (hr *httpRequestKubernetesController) Jwks(c *gin.Context) {
+	return
+}
+
+// Register - Register a user and send the email to verify the address
 func (hr *httpRequestKubernetesController) Register(c *gin.Context) {
 	request, exists := c.Get("request")
 	if !exists {
@@ -41,40 +74,284 @@ func (hr *httpRequestKubernetesController) Register(c *gin.Context) {
 		panic("request is of invalid type")
 	}
 
-	if err := hr.cl.SetUserSecret(c, user.Email, user); err != nil {
+	existing, err := hr.users.GetUser(c, user.Email)
+	if err == nil && existing.Status != userPending {
+		c.JSON(http.StatusConflict, gin.H{"error": errEmailTaken.Error()})
+		return
+	}
+	if err != nil && err != errUserNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := hashPassword(user.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Password = hashedPassword
+	// users listed in ADMIN_EMAILS are promoted once they have verified their address
+	user.Role = roleUser
+	user.Status = userPending
+
+	// registering again overwrites the password and name of a pending account. Whoever registered
+	// first has not proven to own the address, the links sent to them become invalid with the password.
+	if err := hr.users.SetUser(c, user.Email, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := sendVerificationMail(c, user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	uuid, err := hr.cl.GetUuid(c, user.Email)
+	c.JSON(http.StatusAccepted, gin.H{"status": "verification pending"})
+}
+
+// Verify - Verify the email address of a new user and return a JWT with the user object
+func (hr *httpRequestKubernetesController) Verify(c *gin.Context) {
+	if newEmail := c.GetString("newEmail"); newEmail != "" {
+		hr.confirmEmailChange(c, c.GetString("email"), newEmail)
+		return
+	}
+
+	user, err := hr.users.GetUser(c, c.GetString("email"))
+	if err == errUserNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if user.Status != userPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email address has already been verified"})
+		return
+	}
+
+	// the user stays pending if the namespace cannot be created so verifying can be retried
+	if err := ensureUserNamespace(c, hr.cl, user.Uuid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Status: userActive}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Status = userActive
+	if err := promoteConfiguredAdmin(c, hr.users, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	namespace := defaultNamespaceUser + uuid
-	if _, err := hr.cl.CreateNamespace(c, namespace); err != nil {
+	token, refreshToken, err := createToken(*user)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, _, err := createToken(GamebaseUser{
-		Name:  user.Name,
-		Email: user.Email,
+	c.JSON(http.StatusOK, User{
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
 	})
+}
+
+// Move the account to the new email address once the user has proven to own it
+func (hr *httpRequestKubernetesController) confirmEmailChange(c *gin.Context, oldEmail string, newEmail string) {
+	user, err := hr.users.GetUser(c, oldEmail)
+	if err == errUserNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if denial := user.Status.loginDenial(); denial != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": denial})
+		return
+	}
+
+	// the uuid and with it the namespace of the user is moved to the new email address
+	err = hr.users.RenameUser(c, oldEmail, newEmail)
+	if err == errEmailTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Email = newEmail
+
+	// no token of the old address must be accepted anymore
+	if err := revokeAllTokens(c, oldEmail); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := promoteConfiguredAdmin(c, hr.users, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, refreshToken, err := createToken(*user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, User{
-		Email:    user.Email,
-		FullName: user.Name,
-		Token:    token,
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
 	})
+}
+
+// ForgotPassword - Send a link to reset the password to the user
+func (hr *httpRequestKubernetesController) ForgotPassword(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	forgotRequest, ok := request.(PasswordForgot)
+	if !ok {
+		panic("request is of invalid type")
+	}
+
+	// the response is the same whether the user exists or not so it cannot be used to probe for accounts
+	user, err := hr.users.GetUser(c, forgotRequest.Email)
+	if err == nil && user.Status.loginDenial() == "" {
+		token, err := createResetToken(*user)
+		if err == nil {
+			err = mails.Send(c, resetMail(*user, token))
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err != nil && err != errUserNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the account exists an email has been sent"})
+}
+
+// ResetPassword - Set a new password using the token sent by ForgotPassword
+func (hr *httpRequestKubernetesController) ResetPassword(c *gin.Context) {
+	value, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	request, ok := value.(PasswordReset)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	claims := getClaims(c)
+
+	user, err := hr.users.GetUser(c, claims.UserEmail)
+	if err == errUserNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// the token is only valid for the password it has been issued for, which makes it single-use
+	if claims.PasswordFingerprint != passwordFingerprint(user.Password) || user.Status.loginDenial() != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+		return
+	}
+
+	hashedPassword, err := hashPassword(request.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Password: hashedPassword}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// log out all sessions which might have been opened with the old password
+	if err := revokeAllTokens(c, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := revokedTokens.Revoke(c, claims.TokenUuid, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// OidcLogin - Redirect to the OIDC identity provider to log in
+func (hr *httpRequestKubernetesController) OidcLogin(c *gin.Context) {
 	return
 }
 
+// OidcCallback - Finish the login at the OIDC identity provider and return a JWT with the user object
+func (hr *httpRequestKubernetesController) OidcCallback(c *gin.Context) {
+	value, exists := c.Get("identity")
+	if !exists {
+		panic("identity is unset")
+	}
+	identity, ok := value.(oidcIdentity)
+	if !ok {
+		panic("identity is of invalid type")
+	}
+
+	user, err := provisionOidcUser(c, hr.cl, hr.users, identity)
+	switch err {
+	case nil:
+	case errOidcEmailMissing, errOidcEmailUnverified, errOidcRegistration:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errOidcSubjectMismatch:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if denial := user.Status.loginDenial(); denial != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": denial})
+		return
+	}
+
+	// accounts with two-factor authentication might have been linked to the provider by their email address only,
+	// the second factor is therefore verified like for the login with a password
+	if user.TotpSecret != "" {
+		challengeToken, err := createChallengeToken(*user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusAccepted, TwoFactorChallenge{ChallengeToken: challengeToken})
+		return
+	}
+
+	token, refreshToken, err := createToken(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, User{
+		Email:        user.Email,
+		FullName:     user.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
 // ListTemplates - Get a list of all available game server images
 func (hr *httpRequestKubernetesController) ListTemplates(c *gin.Context) {
 	if hr.templates == nil {
@@ -88,23 +365,28 @@ func (hr *httpRequestKubernetesController) ListTemplates(c *gin.Context) {
 	c.JSON(http.StatusOK, templatesList)
 }
 
-// GetStatus - Query status of all deployments
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
 func (hr *httpRequestKubernetesController) GetStatus(c *gin.Context) {
-	id := c.GetString("id")
-	existingGameServers := []*gameServer{}
-	if id == "" {
-		gameServers, err := hr.cl.GetGameServerList(c, getNamespace(c))
-		existingGameServers = gameServers
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
+	if c.GetString("id") != "" {
 		_, existingGameServer := hr.parseIdRequest(c)
 		if existingGameServer == nil {
 			return
 		}
-		existingGameServers = append(existingGameServers, existingGameServer)
+		c.JSON(http.StatusOK, existingGameServer.readGameContainerStatus())
+		return
+	}
+	existingGameServers, err := hr.cl.GetGameServerList(c, getNamespace(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.GetString("organization") == "" {
+		sharedGameServers, err := hr.cl.GetSharedGameServerList(c, strings.TrimPrefix(getNamespace(c), defaultNamespaceUser))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		existingGameServers = append(existingGameServers, sharedGameServers...)
 	}
 	gameContainerStatuses := []*GameContainerStatus{}
 	for _, gameServer := range existingGameServers {
@@ -128,6 +410,7 @@ func (hr *httpRequestKubernetesController) ConfigureContainer(c *gin.Context) {
 	if !ok {
 		panic("request is of invalid type")
 	}
+	c.Set("auditBefore", existingGameServer.readGameContainerStatus().Configuration)
 	updatedGameserver, err := existingGameServer.UpdateGameServer(configurationRequest)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, Exception{Id: existingGameServer.GetUID(), Details: err.Error()})
@@ -145,6 +428,7 @@ func (hr *httpRequestKubernetesController) ConfigureContainer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, Exception{Id: existingGameServer.GetUID(), Details: err.Error()})
 		return
 	}
+	c.Set("auditAfter", updatedGameServer.readGameContainerStatus().Configuration)
 	c.JSON(http.StatusOK, updatedGameServer)
 }
 
@@ -163,14 +447,35 @@ func (hr *httpRequestKubernetesController) DeployContainer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	_, err = hr.cl.DeployTemplate(c, getNamespace(c), template)
+	payload := template.GetUniqueGameServer()
+	if deploymentRequest.Configuration != nil {
+		configured, err := payload.UpdateGameServer(*deploymentRequest.Configuration)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Exception{Details: err.Error()})
+			return
+		}
+		payload = *configured
+	}
+	gameServer, err := hr.cl.DeployGameServer(c, getNamespace(c), &payload)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Exception{Details: err.Error()})
+		status := http.StatusInternalServerError
+		exception := Exception{Details: err.Error()}
+		var deployErr *deploymentError
+		if errors.As(err, &deployErr) {
+			exception.Id = deployErr.uuid
+			exception.Exception = "Deployment of " + deployErr.component + " failed"
+			// the configuration was rejected by Kubernetes
+			if apierrors.IsInvalid(deployErr.err) {
+				status = http.StatusBadRequest
+			}
+		}
+		c.JSON(status, exception)
 		return
 	}
-	h := gin.H{"status": "ok"}
-	c.JSON(http.StatusCreated, h)
-	return
+	c.Set("id", gameServer.GetUID())
+	gameServerStatus := gameServer.readGameContainerStatus()
+	c.Set("auditAfter", gameServerStatus.Configuration)
+	c.JSON(http.StatusCreated, gameServerStatus)
 }
 
 // StartContainer - Start a game server/container
@@ -216,7 +521,7 @@ func (hr *httpRequestKubernetesController) DeleteContainer(c *gin.Context) {
 	if existingGameServer == nil {
 		return
 	}
-	err := hr.cl.DeleteGameserver(c, namespace, existingGameServer)
+	err := hr.cl.DeleteGameserver(c, namespace, existingGameServer, c.GetBool("keepData"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
 		return
@@ -224,6 +529,99 @@ func (hr *httpRequestKubernetesController) DeleteContainer(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// ListGameServerMembers - List the users a game server is shared with
+func (hr *httpRequestKubernetesController) ListGameServerMembers(c *gin.Context) {
+	namespace, existingGameServer := hr.parseIdRequest(c)
+	if existingGameServer == nil {
+		return
+	}
+	users, err := hr.users.ListUsers(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	usersByUuid := map[string]GamebaseUser{}
+	for _, user := range users {
+		usersByUuid[user.Uuid] = user
+	}
+
+	owners := []GameServerMember{}
+	if owner, exists := usersByUuid[strings.TrimPrefix(namespace, defaultNamespaceUser)]; exists {
+		owners = append(owners, owner.toGameServerMember(memberOwner))
+	}
+	members := []GameServerMember{}
+	for uuid, role := range existingGameServer.GetMembers() {
+		// grants of deleted users are ignored
+		if user, exists := usersByUuid[uuid]; exists {
+			members = append(members, user.toGameServerMember(role))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Email < members[j].Email
+	})
+	c.JSON(http.StatusOK, append(owners, members...))
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func (hr *httpRequestKubernetesController) SetGameServerMember(c *gin.Context) {
+	namespace, existingGameServer := hr.parseIdRequest(c)
+	if existingGameServer == nil {
+		return
+	}
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	role, ok := request.(memberRole)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	if namespace == defaultNamespaceUser+user.Uuid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the owner of the game server cannot be a member"})
+		return
+	}
+	current := existingGameServer.GetMembers()[user.Uuid]
+	if (role == memberAdmin || current == memberAdmin) && !getMemberRole(c).includes(memberOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can grant or revoke the admin role"})
+		return
+	}
+	if err := hr.cl.SetGameServerMember(c, namespace, existingGameServer, user.Uuid, role); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: existingGameServer.GetUID(), Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user.toGameServerMember(role))
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func (hr *httpRequestKubernetesController) RemoveGameServerMember(c *gin.Context) {
+	namespace, existingGameServer := hr.parseIdRequest(c)
+	if existingGameServer == nil {
+		return
+	}
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	current, exists := existingGameServer.GetMembers()[user.Uuid]
+	if !exists {
+		c.JSON(http.StatusNotFound, Exception{Id: user.Email, Details: errNotMember.Error()})
+		return
+	}
+	if current == memberAdmin && !getMemberRole(c).includes(memberOwner) && user.Email != getClaims(c).UserEmail {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can grant or revoke the admin role"})
+		return
+	}
+	if err := hr.cl.SetGameServerMember(c, namespace, existingGameServer, user.Uuid, ""); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: existingGameServer.GetUID(), Details: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
 func (hr *httpRequestKubernetesController) UpdateUserProfile(c *gin.Context) {
 	request, exists := c.Get("request")
 	if !exists {
@@ -240,46 +638,865 @@ func (hr *httpRequestKubernetesController) UpdateUserProfile(c *gin.Context) {
 	password := user.Password
 	gamebaseUser := GamebaseUser{
 		Name:     user.Username,
-		Email:    user.Email,
-		Password: password.New,
 		Gravatar: user.Gravatar,
 	}
 
-	k := hr.kubernetesClient()
+	if password.New != "" {
+		hashedPassword, err := hashPassword(password.New)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+			return
+		}
+		gamebaseUser.Password = hashedPassword
+	}
+
+	users := hr.userStore()
 
-	oldSecret, err := k.GetUserSecret(c, oldEmail)
+	oldSecret, err := users.GetUser(c, oldEmail)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
 		return
 	}
 
-	if oldSecret.Password != password.Old {
+	if valid, _ := verifyPassword(oldSecret.Password, password.Old); !valid {
 		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "invalid password"})
 		return
 	}
 
-	newEmail := user.Email
-	if newEmail == "" {
-		newEmail = oldEmail
-	}
-
-	err = k.SetUserSecret(c, newEmail, gamebaseUser)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
-		return
-	}
-
-	if newEmail != oldEmail {
-		err := k.DeleteUserSecret(c, oldEmail)
-		if err != nil {
+	// the email address is changed once the link sent to the new address has been opened, see confirmEmailChange
+	emailChange := user.Email != "" && user.Email != oldEmail
+	if emailChange {
+		_, err := users.GetUser(c, user.Email)
+		if err == nil {
+			c.JSON(http.StatusConflict, Exception{Id: "", Details: errEmailTaken.Error()})
+			return
+		}
+		if err != errUserNotFound {
 			c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
 			return
 		}
-	}
+		token, err := createEmailChangeToken(*oldSecret, user.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+			return
+		}
+		if err := mails.Send(c, emailChangeMail(*oldSecret, user.Email, token)); err != nil {
+			c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+			return
+		}
+	}
+
+	if err := users.SetUser(c, oldEmail, gamebaseUser); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+
+	// the claims of the current token are outdated, replace it by a fresh token pair
+	if err := revokeTokenPair(c, getClaims(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	updatedUser, err := users.GetUser(c, oldEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	token, refreshToken, err := createSessionToken(*updatedUser, getClaims(c).AuthTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if emailChange {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, User{
+		Email:        updatedUser.Email,
+		FullName:     updatedUser.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// GetUserProfile - Get the profile of the authenticated user
+func (hr *httpRequestKubernetesController) GetUserProfile(c *gin.Context) {
+	email, err := extractEmail(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	user, err := hr.users.GetUser(c, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	gameServers, err := hr.cl.GetGameServerList(c, getNamespace(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+
+	usage := ResourceUsage{GameServers: len(gameServers)}
+	for _, gameServer := range gameServers {
+		usage.Storage += gameServer.GetStorageRequest()
+		if gameServer.GetStatus() != STOPPED {
+			usage.RunningGameServers++
+			if limit := gameServer.GetContainerMemoryLimit(); limit > 0 {
+				usage.Memory += int64(limit)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, UserDetails{
+		Email:    user.Email,
+		FullName: user.Name,
+		Gravatar: user.Gravatar,
+		Uuid:     user.Uuid,
+		Created:  user.Created,
+		Role:     string(user.Role.orDefault()),
+		Usage:    usage,
+
+		TwoFactorEnabled: user.TotpSecret != "",
+	})
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func (hr *httpRequestKubernetesController) EnrollTwoFactor(c *gin.Context) {
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+	if user.TotpSecret != "" {
+		c.JSON(http.StatusConflict, Exception{Id: "", Details: "two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := generateTotpSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	// the secret only becomes active once a code has been confirmed
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{TotpPending: secret}); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorEnrollment{
+		Secret:     secret,
+		OtpauthUri: totpUri(user.Email, secret),
+	})
+}
+
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func (hr *httpRequestKubernetesController) ConfirmTwoFactor(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	confirmation, ok := request.(TwoFactorConfirmation)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+	if user.TotpPending == "" {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "no pending two-factor enrollment"})
+		return
+	}
 
+	counter, valid := verifyTotpCode(user.TotpPending, confirmation.Code, 0, time.Now())
+	if !valid {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "invalid code"})
+		return
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	err = hr.users.SetUser(c, user.Email, GamebaseUser{
+		TotpSecret:    user.TotpPending,
+		TotpCounter:   counter,
+		RecoveryCodes: hashes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	// personal access tokens created before are not protected by the second factor
+	if err := hr.users.ClearUserFields(c, user.Email, "totp_pending", "api_tokens"); err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorRecoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor - Disable two-factor authentication
+func (hr *httpRequestKubernetesController) DisableTwoFactor(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	disableRequest, ok := request.(TwoFactorDisable)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+	if user.TotpSecret == "" {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "two-factor authentication is not enabled"})
+		return
+	}
+	if valid, _ := verifyPassword(user.Password, disableRequest.Password); !valid {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "invalid password"})
+		return
+	}
+	valid, err := verifySecondFactor(c, hr.users, user, disableRequest.Code, disableRequest.RecoveryCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "invalid code"})
+		return
+	}
+
+	// like a password reset, the personal access tokens are revoked
+	err = hr.users.ClearUserFields(c, user.Email, "totp_secret", "totp_pending", "totp_counter", "recovery_codes", "api_tokens")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// DeleteUser - Delete the account of the user with all game servers
+func (hr *httpRequestKubernetesController) DeleteUser(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	deletionRequest, ok := request.(UserDeletion)
+	if !ok {
+		panic("request is of invalid type")
+	}
+
+	email, err := extractEmail(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	user, err := hr.users.GetUser(c, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return
+	}
+	// users of the identity provider don't know their password, a recent login confirms the deletion instead
+	if user.OidcSubject != "" && deletionRequest.Password == "" {
+		if !getClaims(c).isRecentLogin(time.Now()) {
+			c.JSON(http.StatusForbidden, Exception{Id: "", Details: "log in again to confirm the deletion"})
+			return
+		}
+	} else if valid, _ := verifyPassword(user.Password, deletionRequest.Password); !valid {
+		c.JSON(http.StatusBadRequest, Exception{Id: "", Details: "invalid password"})
+		return
+	}
+
+	respondAccountDeletion(c, startAccountDeletion(hr.cl, hr.users, email))
+}
+
+// GetAccountDeletion - Query the progress of an account deletion
+func (hr *httpRequestKubernetesController) GetAccountDeletion(c *gin.Context) {
+	id := c.GetString("id")
+	deletion, exists := accountDeletions.get(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, Exception{Id: id, Details: "account deletion does not exist"})
+		return
+	}
+	c.JSON(http.StatusOK, deletion)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func (hr *httpRequestKubernetesController) ListApiTokens(c *gin.Context) {
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+
+	tokens := []ApiToken{}
+	for _, token := range user.ApiTokens {
+		tokens = append(tokens, token.toApiToken())
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateApiToken - Create a personal access token for scripts and automation
+func (hr *httpRequestKubernetesController) CreateApiToken(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	creation, ok := request.(ApiTokenCreation)
+	if !ok {
+		panic("request is of invalid type")
+	}
+
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+
+	var expires time.Time
+	if creation.ExpiresInDays > 0 {
+		expires = time.Now().UTC().Add(time.Duration(creation.ExpiresInDays) * 24 * time.Hour)
+	}
+	secret, token, err := apiTokens.Create(c, user, creation.Name, creation.Scopes, expires)
+	if err == errApiTokenLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	created := token.toApiToken()
+	c.JSON(http.StatusCreated, ApiTokenSecret{
+		Id:      created.Id,
+		Name:    created.Name,
+		Scopes:  created.Scopes,
+		Created: created.Created,
+		Expires: created.Expires,
+		Token:   secret,
+	})
+}
+
+// RevokeApiToken - Revoke a personal access token
+func (hr *httpRequestKubernetesController) RevokeApiToken(c *gin.Context) {
+	user := hr.authenticatedUser(c)
+	if user == nil {
+		return
+	}
+
+	err := apiTokens.Revoke(c, user, c.GetString("id"))
+	if err == errApiTokenNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func (hr *httpRequestKubernetesController) ListInvitations(c *gin.Context) {
+	claims := getClaims(c)
+	list, err := invitations.List(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := []Invitation{}
+	for _, entry := range list {
+		if claims.role().includes(roleAdmin) || entry.CreatedBy == claims.UserEmail {
+			result = append(result, entry.toInvitation())
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func (hr *httpRequestKubernetesController) CreateInvitation(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	creation, ok := request.(InvitationCreation)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	claims := getClaims(c)
+
+	if !claims.role().includes(roleAdmin) {
+		list, err := invitations.List(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		active := 0
+		for _, entry := range list {
+			if entry.CreatedBy == claims.UserEmail && entry.isUsable(time.Now()) {
+				active++
+			}
+		}
+		if active >= maxActiveInvitationsPerUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d unused invitations are allowed", maxActiveInvitationsPerUser)})
+			return
+		}
+	}
+
+	created, err := newInvitation(claims.UserEmail, int(creation.MaxUses), time.Duration(creation.ExpiresInDays)*24*time.Hour)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := invitations.Create(c, created); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created.toInvitation())
+}
+
+// DeleteInvitation - Delete an invitation code
+func (hr *httpRequestKubernetesController) DeleteInvitation(c *gin.Context) {
+	claims := getClaims(c)
+	code := c.GetString("code")
+
+	list, err := invitations.List(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// invitations of other users are reported as missing unless the user is an admin
+	allowed := false
+	for _, entry := range list {
+		if entry.Code == code && (claims.role().includes(roleAdmin) || entry.CreatedBy == claims.UserEmail) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"error": errInvitationNotFound.Error()})
+		return
+	}
+
+	err = invitations.Delete(c, code)
+	if err == errInvitationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func (hr *httpRequestKubernetesController) ListAuditEvents(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	query, ok := request.(auditQuery)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	claims := getClaims(c)
+	if !claims.role().includes(roleAdmin) {
+		// users only see their own actions
+		query.Actor = claims.UserEmail
+	}
+
+	events, total, err := auditLog.Query(c, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, AuditEventList{
+		Events: events,
+		Total:  total,
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	})
+}
+
+// ListOrganizations - List the organizations of the user
+func (hr *httpRequestKubernetesController) ListOrganizations(c *gin.Context) {
+	uuid, err := hr.users.GetUuid(c, getClaims(c).UserEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	list, err := organizations.List(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result := []Organization{}
+	for _, org := range list {
+		if role, isMember := org.Members[uuid]; isMember {
+			result = append(result, org.toOrganization(role))
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateOrganization - Create an organization owned by the user
+func (hr *httpRequestKubernetesController) CreateOrganization(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	creation, ok := request.(OrganizationCreation)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	uuid, err := hr.users.GetUuid(c, getClaims(c).UserEmail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := organizations.List(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	owned := 0
+	for _, org := range list {
+		if org.Members[uuid] == memberOwner {
+			owned++
+		}
+	}
+	if owned >= maxOwnedOrganizationsPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many organizations"})
+		return
+	}
+
+	org := newOrganization(creation.Name, uuid)
+	if _, err := hr.cl.CreateNamespace(c, org.namespace()); err != nil && !apierrors.IsAlreadyExists(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := organizations.Create(c, org); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// recorded by the audit log
+	c.Set("namespace", org.namespace())
+	c.JSON(http.StatusCreated, org.toOrganization(memberOwner))
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func (hr *httpRequestKubernetesController) DeleteOrganization(c *gin.Context) {
+	org := hr.parseOrganizationRequest(c)
+	if org == nil {
+		return
+	}
+	gameServers, err := hr.cl.GetGameServerList(c, org.namespace())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(gameServers) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "the game servers of the organization have to be deleted first"})
+		return
+	}
+	if err := hr.cl.DeleteNamespace(c, org.namespace()); err != nil && !apierrors.IsNotFound(err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := organizations.Delete(c, org.Id); err != nil && err != errOrganizationNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ListOrganizationMembers - List the members of an organization
+func (hr *httpRequestKubernetesController) ListOrganizationMembers(c *gin.Context) {
+	org := hr.parseOrganizationRequest(c)
+	if org == nil {
+		return
+	}
+	users, err := hr.users.ListUsers(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	members := []OrganizationMember{}
+	for _, user := range users {
+		if role, isMember := org.Members[user.Uuid]; isMember {
+			members = append(members, user.toOrganizationMember(role))
+		}
+	}
+	// owners first
+	sort.SliceStable(members, func(i, j int) bool {
+		return memberRole(members[i].Role).level() > memberRole(members[j].Role).level()
+	})
+	c.JSON(http.StatusOK, members)
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func (hr *httpRequestKubernetesController) SetOrganizationMember(c *gin.Context) {
+	org := hr.parseOrganizationRequest(c)
+	if org == nil {
+		return
+	}
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	role, ok := request.(memberRole)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	current := org.Members[user.Uuid]
+	if (role.includes(memberAdmin) || current.includes(memberAdmin)) && !getMemberRole(c).includes(memberOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can grant or revoke the admin and owner roles"})
+		return
+	}
+	err := organizations.Update(c, org.Id, func(o *organization) error {
+		return o.setMember(user.Uuid, role)
+	})
+	if err == errLastOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user.toOrganizationMember(role))
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func (hr *httpRequestKubernetesController) RemoveOrganizationMember(c *gin.Context) {
+	org := hr.parseOrganizationRequest(c)
+	if org == nil {
+		return
+	}
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	current, isMember := org.Members[user.Uuid]
+	if !isMember {
+		c.JSON(http.StatusNotFound, Exception{Id: user.Email, Details: errNotOrganizationMember.Error()})
+		return
+	}
+	if current.includes(memberAdmin) && !getMemberRole(c).includes(memberOwner) && user.Email != getClaims(c).UserEmail {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only owners can grant or revoke the admin and owner roles"})
+		return
+	}
+	err := organizations.Update(c, org.Id, func(o *organization) error {
+		return o.setMember(user.Uuid, "")
+	})
+	if err == errLastOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// AdminListUsers - List all registered users
+func (hr *httpRequestKubernetesController) AdminListUsers(c *gin.Context) {
+	offset := c.GetInt("offset")
+	limit := c.GetInt("limit")
+	users, err := hr.users.ListUsers(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	accounts := []UserAccount{}
+	for i := offset; i < len(users) && i < offset+limit; i++ {
+		accounts = append(accounts, users[i].ToUserAccount())
+	}
+	c.JSON(http.StatusOK, UserAccountList{
+		Users:  accounts,
+		Total:  len(users),
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// AdminListGameServers - List the game servers of all users
+func (hr *httpRequestKubernetesController) AdminListGameServers(c *gin.Context) {
+	users, err := hr.users.ListUsers(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	owners := map[string]string{}
+	for _, user := range users {
+		owners[defaultNamespaceUser+user.Uuid] = user.Email
+	}
+
+	namespaces, err := hr.cl.ListUserNamespaces(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	statuses := []AdminGameContainerStatus{}
+	for _, namespace := range namespaces {
+		gameServers, err := hr.cl.GetGameServerList(c, namespace)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, gameServer := range gameServers {
+			statuses = append(statuses, AdminGameContainerStatus{
+				Owner:     owners[namespace],
+				Namespace: namespace,
+				Server:    gameServer.readGameContainerStatus(),
+			})
+		}
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func (hr *httpRequestKubernetesController) AdminListOrphanedGameServers(c *gin.Context) {
+	orphans, err := hr.orphans.Find(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orphans)
+}
+
+// AdminGetUser - Get a registered user
+func (hr *httpRequestKubernetesController) AdminGetUser(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, user.ToUserAccount())
+}
+
+// AdminSetUserRole - Change the role of a user
+func (hr *httpRequestKubernetesController) AdminSetUserRole(c *gin.Context) {
+	request, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	role, ok := request.(userRole)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	user := hr.parseEmailRequest(c)
+	if user == nil || !hr.isOtherUser(c, user) {
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Role: role}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// tokens carry the role, so the user has to log in again
+	if err := revokeAllTokens(c, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Role = role
+	c.JSON(http.StatusOK, user.ToUserAccount())
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func (hr *httpRequestKubernetesController) AdminDisableUser(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil || !hr.isOtherUser(c, user) {
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Status: userDisabled}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := revokeAllTokens(c, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Status = userDisabled
+	c.JSON(http.StatusOK, user.ToUserAccount())
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func (hr *httpRequestKubernetesController) AdminEnableUser(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	// pending users have to verify their email address, an admin cannot vouch for it
+	if user.Status == userPending {
+		c.JSON(http.StatusConflict, Exception{Id: user.Email, Details: "email address not verified"})
+		return
+	}
+	// creates the namespace if the user has been disabled before it was created
+	if err := ensureUserNamespace(c, hr.cl, user.Uuid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Status: userActive}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user.Status = userActive
+	c.JSON(http.StatusOK, user.ToUserAccount())
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func (hr *httpRequestKubernetesController) AdminResetUserPassword(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	temporaryPassword, err := generateTemporaryPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	hashedPassword, err := hashPassword(temporaryPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := hr.users.SetUser(c, user.Email, GamebaseUser{Password: hashedPassword}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := revokeAllTokens(c, user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, UserPasswordReset{TemporaryPassword: temporaryPassword})
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func (hr *httpRequestKubernetesController) AdminDeleteUser(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil || !hr.isOtherUser(c, user) {
+		return
+	}
+	respondAccountDeletion(c, startAccountDeletion(hr.cl, hr.users, user.Email))
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func (hr *httpRequestKubernetesController) AdminUnlockUser(c *gin.Context) {
+	user := hr.parseEmailRequest(c)
+	if user == nil {
+		return
+	}
+	loginAttempts.Unlock(user.Email)
+	c.JSON(http.StatusOK, user.ToUserAccount())
+}
+
 // Tests if a GameServer Id exists
 func (hr *httpRequestKubernetesController) existstGameServer(id string) {
 }
@@ -301,6 +1518,10 @@ func (hr *httpRequestKubernetesController) parseIdRequest(c *gin.Context) (strin
 	}
 	namespace := getNamespace(c)
 	existingGameServer, err := hr.cl.GetGameServer(c, namespace, id)
+	if err == errGameServerNotFound {
+		c.JSON(http.StatusNotFound, Exception{Id: id, Details: err.Error()})
+		return "", nil
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
 		return "", nil
@@ -321,3 +1542,80 @@ func (hr *httpRequestKubernetesController) rescale(c *gin.Context, replicas int3
 	}
 	return true
 }
+
+// This method is used to parse all requests that specify the target user in the URL
+func (hr *httpRequestKubernetesController) parseEmailRequest(c *gin.Context) *GamebaseUser {
+	email := c.GetString("targetEmail")
+	if email == "" {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: "No email specified"})
+		return nil
+	}
+	user, err := hr.users.GetUser(c, email)
+	if err == errUserNotFound {
+		c.JSON(http.StatusNotFound, Exception{Id: email, Details: err.Error()})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: email, Details: err.Error()})
+		return nil
+	}
+	return user
+}
+
+// Admins must not lock themselves out by disabling, demoting or deleting their own account
+func (hr *httpRequestKubernetesController) isOtherUser(c *gin.Context, user *GamebaseUser) bool {
+	if claims := getClaims(c); claims != nil && claims.UserEmail == user.Email {
+		c.JSON(http.StatusBadRequest, Exception{Id: user.Email, Details: "admins cannot change their own account"})
+		return false
+	}
+	return true
+}
+
+// Answer with the outcome of the account deletion,
+// or with 202 and the progress so far if the cleanup takes longer
+func respondAccountDeletion(c *gin.Context, deletion *accountDeletion) {
+	state := deletion.wait(accountDeletionResponseTimeout)
+	switch state.Status {
+	case deletionCompleted:
+		c.JSON(http.StatusOK, state)
+	case deletionFailed:
+		c.JSON(http.StatusInternalServerError, state)
+	default:
+		c.Header("Location", "/user/deletion/"+state.Id)
+		c.JSON(http.StatusAccepted, state)
+	}
+}
+
+// Lookup the user the authentication token has been issued for
+func (hr *httpRequestKubernetesController) authenticatedUser(c *gin.Context) *GamebaseUser {
+	email, err := extractEmail(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return nil
+	}
+	user, err := hr.users.GetUser(c, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: err.Error()})
+		return nil
+	}
+	return user
+}
+
+// This method is used to parse all requests that target an organization, resolved by extractNamespace
+func (hr *httpRequestKubernetesController) parseOrganizationRequest(c *gin.Context) *organization {
+	id := c.GetString("organization")
+	if id == "" {
+		c.JSON(http.StatusInternalServerError, Exception{Id: "", Details: "No organization specified"})
+		return nil
+	}
+	org, err := organizations.Get(c, id)
+	if err == errOrganizationNotFound {
+		c.JSON(http.StatusNotFound, Exception{Id: id, Details: err.Error()})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Exception{Id: id, Details: err.Error()})
+		return nil
+	}
+	return org
+}
@@ -1,8 +1,18 @@
 package openapi
 
 import (
+	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 500
 )
 
 type httpRequestParser struct {
@@ -17,6 +27,10 @@ func (hr *httpRequestParser) kubernetesClient() kubernetesClient {
 	return hr.nextHandler.kubernetesClient()
 }
 
+func (hr *httpRequestParser) userStore() UserStore {
+	return hr.nextHandler.userStore()
+}
+
 // Login - Login a user and return a JWT with the user object
 func (hr *httpRequestParser) Login(c *gin.Context) {
 	return
@@ -27,6 +41,21 @@ func (hr *httpRequestParser) Logout(c *gin.Context) {
 	return
 }
 
+// Refresh - Exchange a refresh token for a new pair of tokens
+func (hr *httpRequestParser) Refresh(c *gin.Context) {
+	return
+}
+
+// VerifyTwoFactor - Exchange a two-factor challenge token and code for a JWT with the user object
+func (hr *httpRequestParser) VerifyTwoFactor(c *gin.Context) {
+	return
+}
+
+// Jwks - Get the public keys used to sign the JWTs
+func (hr *httpRequestParser) Jwks(c *gin.Context) {
+	return
+}
+
 // Register - Register a user and return a JWT with the user object
 func (hr *httpRequestParser) Register(c *gin.Context) {
 	var request UserRegister
@@ -35,33 +64,128 @@ func (hr *httpRequestParser) Register(c *gin.Context) {
 		return
 	}
 
+	request.Email = normalizeEmail(request.Email)
+	if address, err := mail.ParseAddress(request.Email); err != nil || address.Address != request.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+		return
+	}
+
 	if request.Password != request.ConfirmPassword {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "password must match confirmation password"})
 		return
 	}
 
+	switch registration {
+	case registrationClosed:
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration is closed"})
+		return
+	case registrationInvite:
+		code := normalizeInvitationCode(request.InvitationCode)
+		if code == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "an invitation code is required"})
+			return
+		}
+		err := invitations.Consume(c, code)
+		if err == errInvalidInvitation {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// the use is given back if the registration fails, e.g. because the email address is taken
+		defer func() {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				if err := invitations.Release(context.Background(), code); err != nil {
+					fmt.Println("Could not release invitation " + code + ": " + err.Error())
+				}
+			}
+		}()
+	}
+
 	user := GamebaseUser{Name: request.FullName, Email: request.Email, Password: request.Password}
 	c.Set("request", user)
 	hr.nextHandler.Register(c)
 }
 
+// Verify - Verify the email address of a new user and return a JWT with the user object
+func (hr *httpRequestParser) Verify(c *gin.Context) {
+	//the token has already been checked by the authenticator
+	hr.nextHandler.Verify(c)
+}
+
+// ForgotPassword - Send a link to reset the password to the user
+func (hr *httpRequestParser) ForgotPassword(c *gin.Context) {
+	var request PasswordForgot
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.Email = normalizeEmail(request.Email)
+	if request.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.ForgotPassword(c)
+}
+
+// ResetPassword - Set a new password using the token sent by ForgotPassword
+func (hr *httpRequestParser) ResetPassword(c *gin.Context) {
+	//the request has already been bound by the authenticator to check the token
+	value, exists := c.Get("request")
+	if !exists {
+		panic("request is unset")
+	}
+	request, ok := value.(PasswordReset)
+	if !ok {
+		panic("request is of invalid type")
+	}
+	if request.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password must not be empty"})
+		return
+	}
+	if request.Password != request.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password must match confirmation password"})
+		return
+	}
+	hr.nextHandler.ResetPassword(c)
+}
+
+// OidcLogin - Redirect to the OIDC identity provider to log in
+func (hr *httpRequestParser) OidcLogin(c *gin.Context) {
+	return
+}
+
+// OidcCallback - Finish the login at the OIDC identity provider and return a JWT with the user object
+func (hr *httpRequestParser) OidcCallback(c *gin.Context) {
+	hr.nextHandler.OidcCallback(c)
+}
+
 // ListTemplates - Get a list of all available game server images
 func (hr *httpRequestParser) ListTemplates(c *gin.Context) {
 	//no parameter checks for list
 	hr.nextHandler.ListTemplates(c)
 }
 
-// GetStatus - Query status of all deployments
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
 func (hr *httpRequestParser) GetStatus(c *gin.Context) {
-	//no parameter checks for status
+	if id := c.Param("id"); id != "" {
+		if !isValidGameServerId(id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
+			return
+		}
+		c.Set("id", id)
+	}
 	hr.nextHandler.GetStatus(c)
 }
 
 // ConfigureContainer - Configure a game server based on POST body
 func (hr *httpRequestParser) ConfigureContainer(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
 		return
 	}
 	var request GameContainerConfiguration
@@ -81,6 +205,19 @@ func (hr *httpRequestParser) DeployContainer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if request.Configuration != nil {
+		configuredPath := request.Configuration.Resources.TemplatePath
+		if request.TemplatePath == "" {
+			request.TemplatePath = configuredPath
+		} else if configuredPath != "" && configuredPath != request.TemplatePath {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "templatePath and configuration.resources.templatePath differ"})
+			return
+		}
+	}
+	if request.TemplatePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "templatePath is required"})
+		return
+	}
 	c.Set("request", request)
 	hr.nextHandler.DeployContainer(c)
 }
@@ -88,8 +225,8 @@ func (hr *httpRequestParser) DeployContainer(c *gin.Context) {
 // StartContainer - Start a game server/container
 func (hr *httpRequestParser) StartContainer(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
 		return
 	}
 	c.Set("id", id)
@@ -99,8 +236,8 @@ func (hr *httpRequestParser) StartContainer(c *gin.Context) {
 // StopContainer - Stop a game server/container
 func (hr *httpRequestParser) StopContainer(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
 		return
 	}
 	c.Set("id", id)
@@ -110,8 +247,8 @@ func (hr *httpRequestParser) StopContainer(c *gin.Context) {
 // RestartContainer - Restart a game server/container
 func (hr *httpRequestParser) RestartContainer(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
 		return
 	}
 	c.Set("id", id)
@@ -121,20 +258,433 @@ func (hr *httpRequestParser) RestartContainer(c *gin.Context) {
 // DeleteContainer - Delete deployment of game server
 func (hr *httpRequestParser) DeleteContainer(c *gin.Context) {
 	id := c.Param("id")
-	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
+		return
+	}
+	keepData, err := strconv.ParseBool(c.DefaultQuery("keepData", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keepData must be true or false"})
 		return
 	}
 	c.Set("id", id)
+	c.Set("keepData", keepData)
 	hr.nextHandler.DeleteContainer(c)
 }
 
+// ListGameServerMembers - List the users a game server is shared with
+func (hr *httpRequestParser) ListGameServerMembers(c *gin.Context) {
+	id := c.Param("id")
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
+		return
+	}
+	c.Set("id", id)
+	hr.nextHandler.ListGameServerMembers(c)
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func (hr *httpRequestParser) SetGameServerMember(c *gin.Context) {
+	id := c.Param("id")
+	email := normalizeEmail(c.Param("email"))
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
+		return
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	var request GameServerMemberUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !isGrantableMemberRole(memberRole(request.Role)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of viewer, operator or admin"})
+		return
+	}
+	c.Set("id", id)
+	c.Set("targetEmail", email)
+	c.Set("request", memberRole(request.Role))
+	hr.nextHandler.SetGameServerMember(c)
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func (hr *httpRequestParser) RemoveGameServerMember(c *gin.Context) {
+	id := c.Param("id")
+	email := normalizeEmail(c.Param("email"))
+	if !isValidGameServerId(id) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidGameServerId.Error()})
+		return
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("id", id)
+	c.Set("targetEmail", email)
+	hr.nextHandler.RemoveGameServerMember(c)
+}
+
 func (hr *httpRequestParser) UpdateUserProfile(c *gin.Context) {
 	var request UserProfile
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	request.Email = normalizeEmail(request.Email)
+	if request.Email != "" {
+		if address, err := mail.ParseAddress(request.Email); err != nil || address.Address != request.Email {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+			return
+		}
+	}
 	c.Set("request", request)
 	hr.nextHandler.UpdateUserProfile(c)
 }
+
+// GetUserProfile - Get the profile of the authenticated user
+func (hr *httpRequestParser) GetUserProfile(c *gin.Context) {
+	//no parameter checks for profile
+	hr.nextHandler.GetUserProfile(c)
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func (hr *httpRequestParser) EnrollTwoFactor(c *gin.Context) {
+	//no parameter checks for enrollment
+	hr.nextHandler.EnrollTwoFactor(c)
+}
+
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func (hr *httpRequestParser) ConfirmTwoFactor(c *gin.Context) {
+	var request TwoFactorConfirmation
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.ConfirmTwoFactor(c)
+}
+
+// DisableTwoFactor - Disable two-factor authentication
+func (hr *httpRequestParser) DisableTwoFactor(c *gin.Context) {
+	var request TwoFactorDisable
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Code == "" && request.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery code is required"})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.DisableTwoFactor(c)
+}
+
+// DeleteUser - Delete the account of the user with all game servers
+func (hr *httpRequestParser) DeleteUser(c *gin.Context) {
+	var request UserDeletion
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.DeleteUser(c)
+}
+
+// GetAccountDeletion - Query the progress of an account deletion
+func (hr *httpRequestParser) GetAccountDeletion(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("id", id)
+	hr.nextHandler.GetAccountDeletion(c)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func (hr *httpRequestParser) ListApiTokens(c *gin.Context) {
+	hr.nextHandler.ListApiTokens(c)
+}
+
+// CreateApiToken - Create a personal access token for scripts and automation
+func (hr *httpRequestParser) CreateApiToken(c *gin.Context) {
+	var request ApiTokenCreation
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+		return
+	}
+	if len(request.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one scope is required"})
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !isValidApiTokenScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope + ", valid scopes are " + strings.Join(apiTokenScopes, ", ")})
+			return
+		}
+	}
+	if request.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays must not be negative"})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.CreateApiToken(c)
+}
+
+// RevokeApiToken - Revoke a personal access token
+func (hr *httpRequestParser) RevokeApiToken(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("id", id)
+	hr.nextHandler.RevokeApiToken(c)
+}
+
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func (hr *httpRequestParser) ListInvitations(c *gin.Context) {
+	hr.nextHandler.ListInvitations(c)
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func (hr *httpRequestParser) CreateInvitation(c *gin.Context) {
+	request := InvitationCreation{MaxUses: defaultInvitationUses, ExpiresInDays: defaultInvitationExpiryDays}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.MaxUses < 1 || request.MaxUses > maxInvitationUses {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "maxUses must be between 1 and " + strconv.Itoa(maxInvitationUses)})
+		return
+	}
+	if request.ExpiresInDays < 1 || request.ExpiresInDays > maxInvitationExpiryDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresInDays must be between 1 and " + strconv.Itoa(maxInvitationExpiryDays)})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.CreateInvitation(c)
+}
+
+// DeleteInvitation - Delete an invitation code
+func (hr *httpRequestParser) DeleteInvitation(c *gin.Context) {
+	code := normalizeInvitationCode(c.Param("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("code", code)
+	hr.nextHandler.DeleteInvitation(c)
+}
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func (hr *httpRequestParser) ListAuditEvents(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative number"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultAuditPageSize)))
+	if err != nil || limit < 1 || limit > maxAuditPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxAuditPageSize)})
+		return
+	}
+	c.Set("request", auditQuery{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		Offset: offset,
+		Limit:  limit,
+	})
+	hr.nextHandler.ListAuditEvents(c)
+}
+
+// ListOrganizations - List the organizations of the user
+func (hr *httpRequestParser) ListOrganizations(c *gin.Context) {
+	//no parameter checks for list
+	hr.nextHandler.ListOrganizations(c)
+}
+
+// CreateOrganization - Create an organization owned by the user
+func (hr *httpRequestParser) CreateOrganization(c *gin.Context) {
+	var request OrganizationCreation
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || len(request.Name) > maxOrganizationNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must have between 1 and " + strconv.Itoa(maxOrganizationNameLength) + " characters"})
+		return
+	}
+	c.Set("request", request)
+	hr.nextHandler.CreateOrganization(c)
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func (hr *httpRequestParser) DeleteOrganization(c *gin.Context) {
+	//the organization has already been resolved by the authenticator
+	hr.nextHandler.DeleteOrganization(c)
+}
+
+// ListOrganizationMembers - List the members of an organization
+func (hr *httpRequestParser) ListOrganizationMembers(c *gin.Context) {
+	//the organization has already been resolved by the authenticator
+	hr.nextHandler.ListOrganizationMembers(c)
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func (hr *httpRequestParser) SetOrganizationMember(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	var request OrganizationMemberUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if role := memberRole(request.Role); !isGrantableMemberRole(role) && role != memberOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of viewer, operator, admin or owner"})
+		return
+	}
+	c.Set("targetEmail", email)
+	c.Set("request", memberRole(request.Role))
+	hr.nextHandler.SetOrganizationMember(c)
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func (hr *httpRequestParser) RemoveOrganizationMember(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.RemoveOrganizationMember(c)
+}
+
+// AdminListUsers - List all registered users
+func (hr *httpRequestParser) AdminListUsers(c *gin.Context) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative number"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultUserPageSize)))
+	if err != nil || limit < 1 || limit > maxUserPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxUserPageSize)})
+		return
+	}
+	c.Set("offset", offset)
+	c.Set("limit", limit)
+	hr.nextHandler.AdminListUsers(c)
+}
+
+// AdminListGameServers - List the game servers of all users
+func (hr *httpRequestParser) AdminListGameServers(c *gin.Context) {
+	//no parameter checks for list
+	hr.nextHandler.AdminListGameServers(c)
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func (hr *httpRequestParser) AdminListOrphanedGameServers(c *gin.Context) {
+	//no parameter checks for list
+	hr.nextHandler.AdminListOrphanedGameServers(c)
+}
+
+// AdminSetUserRole - Change the role of a user
+func (hr *httpRequestParser) AdminSetUserRole(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	var request UserRoleUpdate
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !isValidUserRole(userRole(request.Role)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of user, operator or admin"})
+		return
+	}
+	c.Set("targetEmail", email)
+	c.Set("request", userRole(request.Role))
+	hr.nextHandler.AdminSetUserRole(c)
+}
+
+// AdminGetUser - Get a registered user
+func (hr *httpRequestParser) AdminGetUser(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminGetUser(c)
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func (hr *httpRequestParser) AdminDisableUser(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminDisableUser(c)
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func (hr *httpRequestParser) AdminEnableUser(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminEnableUser(c)
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func (hr *httpRequestParser) AdminResetUserPassword(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminResetUserPassword(c)
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func (hr *httpRequestParser) AdminDeleteUser(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminDeleteUser(c)
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func (hr *httpRequestParser) AdminUnlockUser(c *gin.Context) {
+	email := normalizeEmail(c.Param("email"))
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "error"})
+		return
+	}
+	c.Set("targetEmail", email)
+	hr.nextHandler.AdminUnlockUser(c)
+}
@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/twinj/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// Reports the game server id passed on by the parser
+type gameServerIdRecorder struct {
+	httpRequestHandler
+}
+
+func (hr gameServerIdRecorder) StartContainer(c *gin.Context) {
+	c.String(http.StatusOK, c.GetString("id"))
+}
+
+func TestGameServerIdValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hr := &httpRequestParser{nextHandler: gameServerIdRecorder{}}
+	router := gin.New()
+	router.POST("/gs/start/:id", hr.StartContainer)
+
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"uuid", uuid.NewV4().String(), true},
+		{"dns label", "minecraft-1", true},
+		{"selector requirement", "abc,name=test", false},
+		{"selector operator", "abc!=def", false},
+		{"upper case", "ABC", false},
+		{"space", "abc def", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/gs/start/"+url.PathEscape(test.id), nil))
+			if test.valid && (recorder.Code != http.StatusOK || recorder.Body.String() != test.id) {
+				t.Errorf("status %d %s, want the id to be passed on", recorder.Code, recorder.Body.String())
+			}
+			if !test.valid && recorder.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want %d", recorder.Code, http.StatusBadRequest)
+			}
+
+			if !test.valid {
+				// the authenticator looks up shared game servers before the parser runs
+				c, _ := gin.CreateTestContext(httptest.NewRecorder())
+				c.Params = gin.Params{{Key: "id", Value: test.id}}
+				if err := extractGameServerNamespace(c, kubernetesClient{}, nil); err != errInvalidGameServerId {
+					t.Errorf("extractGameServerNamespace() error = %v, want %v", err, errInvalidGameServerId)
+				}
+			}
+		})
+	}
+}
@@ -18,7 +18,7 @@ type HttpRequestProcessingChain struct {
 // This Method returns a Singleton.
 func NewHttpRequestProcessingChain() *HttpRequestProcessingChain {
 	once.Do(func() {
-		instantiated = &HttpRequestProcessingChain{nextHandler: newHttpRequestAuthenticator()}
+		instantiated = &HttpRequestProcessingChain{nextHandler: newHttpRequestAuditor()}
 	})
 	return instantiated
 }
@@ -33,11 +33,51 @@ func (hr *HttpRequestProcessingChain) Logout(c *gin.Context) {
 	hr.nextHandler.Logout(c)
 }
 
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) Refresh(c *gin.Context) {
+	hr.nextHandler.Refresh(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) VerifyTwoFactor(c *gin.Context) {
+	hr.nextHandler.VerifyTwoFactor(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) Jwks(c *gin.Context) {
+	hr.nextHandler.Jwks(c)
+}
+
 // Pass Function Call to first httpRequestHandler
 func (hr *HttpRequestProcessingChain) Register(c *gin.Context) {
 	hr.nextHandler.Register(c)
 }
 
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) Verify(c *gin.Context) {
+	hr.nextHandler.Verify(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ForgotPassword(c *gin.Context) {
+	hr.nextHandler.ForgotPassword(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ResetPassword(c *gin.Context) {
+	hr.nextHandler.ResetPassword(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) OidcLogin(c *gin.Context) {
+	hr.nextHandler.OidcLogin(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) OidcCallback(c *gin.Context) {
+	hr.nextHandler.OidcCallback(c)
+}
+
 // Pass Function Call to first httpRequestHandler
 func (hr *HttpRequestProcessingChain) ListTemplates(c *gin.Context) {
 	hr.nextHandler.ListTemplates(c)
@@ -78,6 +118,166 @@ func (hr *HttpRequestProcessingChain) DeleteContainer(c *gin.Context) {
 	hr.nextHandler.DeleteContainer(c)
 }
 
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListGameServerMembers(c *gin.Context) {
+	hr.nextHandler.ListGameServerMembers(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) SetGameServerMember(c *gin.Context) {
+	hr.nextHandler.SetGameServerMember(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) RemoveGameServerMember(c *gin.Context) {
+	hr.nextHandler.RemoveGameServerMember(c)
+}
+
 func (hr *HttpRequestProcessingChain) UpdateUserProfile(c *gin.Context) {
 	hr.nextHandler.UpdateUserProfile(c)
 }
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) GetUserProfile(c *gin.Context) {
+	hr.nextHandler.GetUserProfile(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) EnrollTwoFactor(c *gin.Context) {
+	hr.nextHandler.EnrollTwoFactor(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ConfirmTwoFactor(c *gin.Context) {
+	hr.nextHandler.ConfirmTwoFactor(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) DisableTwoFactor(c *gin.Context) {
+	hr.nextHandler.DisableTwoFactor(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) DeleteUser(c *gin.Context) {
+	hr.nextHandler.DeleteUser(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) GetAccountDeletion(c *gin.Context) {
+	hr.nextHandler.GetAccountDeletion(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListApiTokens(c *gin.Context) {
+	hr.nextHandler.ListApiTokens(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) CreateApiToken(c *gin.Context) {
+	hr.nextHandler.CreateApiToken(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) RevokeApiToken(c *gin.Context) {
+	hr.nextHandler.RevokeApiToken(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListInvitations(c *gin.Context) {
+	hr.nextHandler.ListInvitations(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) CreateInvitation(c *gin.Context) {
+	hr.nextHandler.CreateInvitation(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) DeleteInvitation(c *gin.Context) {
+	hr.nextHandler.DeleteInvitation(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListAuditEvents(c *gin.Context) {
+	hr.nextHandler.ListAuditEvents(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListOrganizations(c *gin.Context) {
+	hr.nextHandler.ListOrganizations(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) CreateOrganization(c *gin.Context) {
+	hr.nextHandler.CreateOrganization(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) DeleteOrganization(c *gin.Context) {
+	hr.nextHandler.DeleteOrganization(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) ListOrganizationMembers(c *gin.Context) {
+	hr.nextHandler.ListOrganizationMembers(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) SetOrganizationMember(c *gin.Context) {
+	hr.nextHandler.SetOrganizationMember(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) RemoveOrganizationMember(c *gin.Context) {
+	hr.nextHandler.RemoveOrganizationMember(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminListUsers(c *gin.Context) {
+	hr.nextHandler.AdminListUsers(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminListGameServers(c *gin.Context) {
+	hr.nextHandler.AdminListGameServers(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminListOrphanedGameServers(c *gin.Context) {
+	hr.nextHandler.AdminListOrphanedGameServers(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminGetUser(c *gin.Context) {
+	hr.nextHandler.AdminGetUser(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminSetUserRole(c *gin.Context) {
+	hr.nextHandler.AdminSetUserRole(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminDisableUser(c *gin.Context) {
+	hr.nextHandler.AdminDisableUser(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminEnableUser(c *gin.Context) {
+	hr.nextHandler.AdminEnableUser(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminResetUserPassword(c *gin.Context) {
+	hr.nextHandler.AdminResetUserPassword(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminDeleteUser(c *gin.Context) {
+	hr.nextHandler.AdminDeleteUser(c)
+}
+
+// Pass Function Call to first httpRequestHandler
+func (hr *HttpRequestProcessingChain) AdminUnlockUser(c *gin.Context) {
+	hr.nextHandler.AdminUnlockUser(c)
+}
@@ -0,0 +1,421 @@
+package openapi
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Checks the role of the authenticated user before the request is passed on.
+// Relies on the claims stored in the context by the httpRequestAuthenticator.
+type httpRequestRoleAuthorizer struct {
+	nextHandler httpRequestHandler
+}
+
+func newHttpRequestRoleAuthorizer() *httpRequestRoleAuthorizer {
+	return &httpRequestRoleAuthorizer{nextHandler: newHttpRequestParser()}
+}
+
+func (hr *httpRequestRoleAuthorizer) kubernetesClient() kubernetesClient {
+	return hr.nextHandler.kubernetesClient()
+}
+
+func (hr *httpRequestRoleAuthorizer) userStore() UserStore {
+	return hr.nextHandler.userStore()
+}
+
+// Login - Login a user and return a JWT with the user object
+func (hr *httpRequestRoleAuthorizer) Login(c *gin.Context) {
+	hr.nextHandler.Login(c)
+}
+
+// Logout - Invalidate the passed JWT
+func (hr *httpRequestRoleAuthorizer) Logout(c *gin.Context) {
+	hr.nextHandler.Logout(c)
+}
+
+// Refresh - Exchange a refresh token for a new pair of tokens
+func (hr *httpRequestRoleAuthorizer) Refresh(c *gin.Context) {
+	hr.nextHandler.Refresh(c)
+}
+
+// VerifyTwoFactor - Exchange a two-factor challenge token and code for a JWT with the user object
+func (hr *httpRequestRoleAuthorizer) VerifyTwoFactor(c *gin.Context) {
+	hr.nextHandler.VerifyTwoFactor(c)
+}
+
+// Jwks - Get the public keys used to sign the JWTs
+func (hr *httpRequestRoleAuthorizer) Jwks(c *gin.Context) {
+	hr.nextHandler.Jwks(c)
+}
+
+// Register - Register a user and return a JWT with the user object
+func (hr *httpRequestRoleAuthorizer) Register(c *gin.Context) {
+	hr.nextHandler.Register(c)
+}
+
+// Verify - Verify the email address of a new user and return a JWT with the user object
+func (hr *httpRequestRoleAuthorizer) Verify(c *gin.Context) {
+	hr.nextHandler.Verify(c)
+}
+
+// ForgotPassword - Send a link to reset the password to the user
+func (hr *httpRequestRoleAuthorizer) ForgotPassword(c *gin.Context) {
+	hr.nextHandler.ForgotPassword(c)
+}
+
+// ResetPassword - Set a new password using the token sent by ForgotPassword
+func (hr *httpRequestRoleAuthorizer) ResetPassword(c *gin.Context) {
+	hr.nextHandler.ResetPassword(c)
+}
+
+// OidcLogin - Redirect to the OIDC identity provider to log in
+func (hr *httpRequestRoleAuthorizer) OidcLogin(c *gin.Context) {
+	return
+}
+
+// OidcCallback - Finish the login at the OIDC identity provider and return a JWT with the user object
+func (hr *httpRequestRoleAuthorizer) OidcCallback(c *gin.Context) {
+	hr.nextHandler.OidcCallback(c)
+}
+
+// ListTemplates - Get a list of all available game server images
+func (hr *httpRequestRoleAuthorizer) ListTemplates(c *gin.Context) {
+	if requireScope(c, scopeGameServerRead) {
+		hr.nextHandler.ListTemplates(c)
+	}
+}
+
+// GetStatus - Query status of all deployments, or of a single one if an id is passed
+func (hr *httpRequestRoleAuthorizer) GetStatus(c *gin.Context) {
+	if requireScope(c, scopeGameServerRead) {
+		hr.nextHandler.GetStatus(c)
+	}
+}
+
+// ConfigureContainer - Configure a game server based on POST body
+func (hr *httpRequestRoleAuthorizer) ConfigureContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerWrite) && requireMemberRole(c, memberAdmin) {
+		hr.nextHandler.ConfigureContainer(c)
+	}
+}
+
+// DeployContainer - Deploy a game server based on POST body
+func (hr *httpRequestRoleAuthorizer) DeployContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerWrite) && requireMemberRole(c, memberAdmin) {
+		hr.nextHandler.DeployContainer(c)
+	}
+}
+
+// StartContainer - Start a game server/container
+func (hr *httpRequestRoleAuthorizer) StartContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerControl) && requireMemberRole(c, memberOperator) {
+		hr.nextHandler.StartContainer(c)
+	}
+}
+
+// StopContainer - Stop a game server/container
+func (hr *httpRequestRoleAuthorizer) StopContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerControl) && requireMemberRole(c, memberOperator) {
+		hr.nextHandler.StopContainer(c)
+	}
+}
+
+// RestartContainer - Restart a game server/container
+func (hr *httpRequestRoleAuthorizer) RestartContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerControl) && requireMemberRole(c, memberOperator) {
+		hr.nextHandler.RestartContainer(c)
+	}
+}
+
+// DeleteContainer - Delete deployment of game server
+func (hr *httpRequestRoleAuthorizer) DeleteContainer(c *gin.Context) {
+	if requireScope(c, scopeGameServerWrite) && requireMemberRole(c, memberOwner) {
+		hr.nextHandler.DeleteContainer(c)
+	}
+}
+
+// ListGameServerMembers - List the users a game server is shared with
+func (hr *httpRequestRoleAuthorizer) ListGameServerMembers(c *gin.Context) {
+	if requireScope(c, scopeGameServerRead) && requireMemberRole(c, memberViewer) {
+		hr.nextHandler.ListGameServerMembers(c)
+	}
+}
+
+// SetGameServerMember - Share a game server with a user or change the access of a member
+func (hr *httpRequestRoleAuthorizer) SetGameServerMember(c *gin.Context) {
+	if requireScope(c, scopeGameServerWrite) && requireMemberRole(c, memberAdmin) {
+		hr.nextHandler.SetGameServerMember(c)
+	}
+}
+
+// RemoveGameServerMember - Revoke the access of a member to a game server
+func (hr *httpRequestRoleAuthorizer) RemoveGameServerMember(c *gin.Context) {
+	// every member can leave a game server
+	required := memberAdmin
+	if claims := getClaims(c); claims != nil && claims.UserEmail == normalizeEmail(c.Param("email")) {
+		required = memberViewer
+	}
+	if requireScope(c, scopeGameServerWrite) && requireMemberRole(c, required) {
+		hr.nextHandler.RemoveGameServerMember(c)
+	}
+}
+
+func (hr *httpRequestRoleAuthorizer) UpdateUserProfile(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.UpdateUserProfile(c)
+	}
+}
+
+// GetUserProfile - Get the profile of the authenticated user
+func (hr *httpRequestRoleAuthorizer) GetUserProfile(c *gin.Context) {
+	if requireScope(c, scopeProfileRead) {
+		hr.nextHandler.GetUserProfile(c)
+	}
+}
+
+// EnrollTwoFactor - Start enabling two-factor authentication
+func (hr *httpRequestRoleAuthorizer) EnrollTwoFactor(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.EnrollTwoFactor(c)
+	}
+}
+
+// ConfirmTwoFactor - Enable two-factor authentication by verifying the first code
+func (hr *httpRequestRoleAuthorizer) ConfirmTwoFactor(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.ConfirmTwoFactor(c)
+	}
+}
+
+// DisableTwoFactor - Disable two-factor authentication
+func (hr *httpRequestRoleAuthorizer) DisableTwoFactor(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.DisableTwoFactor(c)
+	}
+}
+
+// DeleteUser - Delete the account of the user with all game servers
+func (hr *httpRequestRoleAuthorizer) DeleteUser(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.DeleteUser(c)
+	}
+}
+
+// GetAccountDeletion - Query the progress of an account deletion
+func (hr *httpRequestRoleAuthorizer) GetAccountDeletion(c *gin.Context) {
+	hr.nextHandler.GetAccountDeletion(c)
+}
+
+// ListApiTokens - List the personal access tokens of the user
+func (hr *httpRequestRoleAuthorizer) ListApiTokens(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.ListApiTokens(c)
+	}
+}
+
+// CreateApiToken - Create a personal access token for scripts and automation
+func (hr *httpRequestRoleAuthorizer) CreateApiToken(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.CreateApiToken(c)
+	}
+}
+
+// RevokeApiToken - Revoke a personal access token
+func (hr *httpRequestRoleAuthorizer) RevokeApiToken(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.RevokeApiToken(c)
+	}
+}
+
+// ListInvitations - List the invitations created by the user, admins get all invitations
+func (hr *httpRequestRoleAuthorizer) ListInvitations(c *gin.Context) {
+	if requireRole(c, invitationRole) {
+		hr.nextHandler.ListInvitations(c)
+	}
+}
+
+// CreateInvitation - Create an invitation code for the registration
+func (hr *httpRequestRoleAuthorizer) CreateInvitation(c *gin.Context) {
+	if requireRole(c, invitationRole) {
+		hr.nextHandler.CreateInvitation(c)
+	}
+}
+
+// DeleteInvitation - Delete an invitation code
+func (hr *httpRequestRoleAuthorizer) DeleteInvitation(c *gin.Context) {
+	if requireRole(c, invitationRole) {
+		hr.nextHandler.DeleteInvitation(c)
+	}
+}
+
+// ListAuditEvents - List the recorded actions of the user, admins get the actions of all users
+func (hr *httpRequestRoleAuthorizer) ListAuditEvents(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.ListAuditEvents(c)
+	}
+}
+
+// ListOrganizations - List the organizations of the user
+func (hr *httpRequestRoleAuthorizer) ListOrganizations(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.ListOrganizations(c)
+	}
+}
+
+// CreateOrganization - Create an organization owned by the user
+func (hr *httpRequestRoleAuthorizer) CreateOrganization(c *gin.Context) {
+	if requireRole(c, roleUser) {
+		hr.nextHandler.CreateOrganization(c)
+	}
+}
+
+// DeleteOrganization - Delete an organization without game servers
+func (hr *httpRequestRoleAuthorizer) DeleteOrganization(c *gin.Context) {
+	if requireRole(c, roleUser) && requireMemberRole(c, memberOwner) {
+		hr.nextHandler.DeleteOrganization(c)
+	}
+}
+
+// ListOrganizationMembers - List the members of an organization
+func (hr *httpRequestRoleAuthorizer) ListOrganizationMembers(c *gin.Context) {
+	if requireRole(c, roleUser) && requireMemberRole(c, memberViewer) {
+		hr.nextHandler.ListOrganizationMembers(c)
+	}
+}
+
+// SetOrganizationMember - Add a user to an organization or change the role of a member
+func (hr *httpRequestRoleAuthorizer) SetOrganizationMember(c *gin.Context) {
+	if requireRole(c, roleUser) && requireMemberRole(c, memberAdmin) {
+		hr.nextHandler.SetOrganizationMember(c)
+	}
+}
+
+// RemoveOrganizationMember - Remove a member from an organization
+func (hr *httpRequestRoleAuthorizer) RemoveOrganizationMember(c *gin.Context) {
+	// every member can leave an organization
+	required := memberAdmin
+	if claims := getClaims(c); claims != nil && claims.UserEmail == normalizeEmail(c.Param("email")) {
+		required = memberViewer
+	}
+	if requireRole(c, roleUser) && requireMemberRole(c, required) {
+		hr.nextHandler.RemoveOrganizationMember(c)
+	}
+}
+
+// AdminListUsers - List all registered users
+func (hr *httpRequestRoleAuthorizer) AdminListUsers(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminListUsers(c)
+	}
+}
+
+// AdminListGameServers - List the game servers of all users
+func (hr *httpRequestRoleAuthorizer) AdminListGameServers(c *gin.Context) {
+	if requireRole(c, roleOperator) {
+		hr.nextHandler.AdminListGameServers(c)
+	}
+}
+
+// AdminListOrphanedGameServers - List game servers with missing or duplicate components
+func (hr *httpRequestRoleAuthorizer) AdminListOrphanedGameServers(c *gin.Context) {
+	if requireRole(c, roleOperator) {
+		hr.nextHandler.AdminListOrphanedGameServers(c)
+	}
+}
+
+// AdminGetUser - Get a registered user
+func (hr *httpRequestRoleAuthorizer) AdminGetUser(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminGetUser(c)
+	}
+}
+
+// AdminSetUserRole - Change the role of a user
+func (hr *httpRequestRoleAuthorizer) AdminSetUserRole(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminSetUserRole(c)
+	}
+}
+
+// AdminDisableUser - Prevent a user from logging in
+func (hr *httpRequestRoleAuthorizer) AdminDisableUser(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminDisableUser(c)
+	}
+}
+
+// AdminEnableUser - Allow a disabled user to log in again
+func (hr *httpRequestRoleAuthorizer) AdminEnableUser(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminEnableUser(c)
+	}
+}
+
+// AdminResetUserPassword - Replace the password of a user by a temporary password
+func (hr *httpRequestRoleAuthorizer) AdminResetUserPassword(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminResetUserPassword(c)
+	}
+}
+
+// AdminDeleteUser - Delete a user together with the user namespace and all game servers
+func (hr *httpRequestRoleAuthorizer) AdminDeleteUser(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminDeleteUser(c)
+	}
+}
+
+// AdminUnlockUser - Lift the login lockout of a user after too many failed attempts
+func (hr *httpRequestRoleAuthorizer) AdminUnlockUser(c *gin.Context) {
+	if requireRole(c, roleAdmin) {
+		hr.nextHandler.AdminUnlockUser(c)
+	}
+}
+
+// Check that the authenticated user has at least the required role,
+// otherwise respond with 403
+func requireRole(c *gin.Context, required userRole) bool {
+	claims := getClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return false
+	}
+	if claims.TokenType == apiTokenType {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed with a personal access token"})
+		return false
+	}
+	if !claims.role().includes(required) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return false
+	}
+	return true
+}
+
+// Check that the authenticated user has at least the user role and, in case of a
+// personal access token, the scope. Endpoints checked by requireRole reject these tokens.
+func requireScope(c *gin.Context, scope string) bool {
+	claims := getClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authentication token"})
+		return false
+	}
+	if !claims.role().includes(roleUser) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		return false
+	}
+	if !claims.hasScope(scope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "the token lacks the scope " + scope})
+		return false
+	}
+	return true
+}
+
+// Check that the access of the user to the namespace or game server,
+// resolved by extractNamespace or extractGameServerNamespace, includes the required role
+func requireMemberRole(c *gin.Context, required memberRole) bool {
+	if !getMemberRole(c).includes(required) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions on the game server"})
+		return false
+	}
+	return true
+}
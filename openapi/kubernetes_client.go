@@ -5,13 +5,16 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	uuidGen "github.com/twinj/uuid"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
-	"math/rand"
+	"k8s.io/client-go/util/retry"
 	"path/filepath"
 	"strings"
 	"time"
@@ -20,6 +23,14 @@ import (
 const defaultNamespace = "gamebaseprefix"
 const defaultNamespaceUser = defaultNamespace + "-user-"
 
+var errGameServerNotFound = errors.New("game server does not exist")
+
+// Label of PVCs which were kept on the deletion of their game server
+const retainedLabel = "gamebase.gahr.dev/retained"
+
+// Label of the user secrets to find users by their uuid, set whenever the user is stored
+const userUuidLabel = "gamebase.gahr.dev/user-uuid"
+
 type kubernetesClient struct {
 	Client *kubernetes.Clientset
 }
@@ -66,7 +77,17 @@ func (k kubernetesClient) GetGameServerList(ctx context.Context, namespace strin
 	return gameServers, nil
 }
 
+// Fails with errGameServerNotFound if there is no Deployment for the UUID
 func (k kubernetesClient) GetGameServer(ctx context.Context, namespace string, uuid string) (*gameServer, error) {
+	existingDeployment, err := k.Client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: "deploymentUUID=" + uuid})
+	if err != nil {
+		return nil, err
+	}
+	if num := len(existingDeployment.Items); num == 0 {
+		return nil, errGameServerNotFound
+	} else if num != 1 {
+		return nil, errors.New("Number of selected Deployments for UUID " + uuid + " == " + fmt.Sprint(num) + " should be 1")
+	}
 	existingConfigMap, err := k.Client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: "deploymentUUID=" + uuid})
 	if err != nil {
 		return nil, err
@@ -81,13 +102,6 @@ func (k kubernetesClient) GetGameServer(ctx context.Context, namespace string, u
 	if num := len(existingPVC.Items); num != 1 {
 		return nil, errors.New("Number of selected PVCs for UUID " + uuid + " == " + fmt.Sprint(num) + " should be 1")
 	}
-	existingDeployment, err := k.Client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: "deploymentUUID=" + uuid})
-	if err != nil {
-		return nil, err
-	}
-	if num := len(existingDeployment.Items); num != 1 {
-		return nil, errors.New("Number of selected Deployments for UUID " + uuid + " == " + fmt.Sprint(num) + " should be 1")
-	}
 	existingService, err := k.Client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: "deploymentUUID=" + uuid})
 	if err != nil {
 		return nil, err
@@ -103,20 +117,72 @@ func (k kubernetesClient) GetGameServer(ctx context.Context, namespace string, u
 	}, nil
 }
 
-func (k kubernetesClient) DeployTemplate(ctx context.Context, namespace string, template *gameServerTemplate) (*gameServer, error) {
-	deploymentPayload := template.GetUniqueGameServer()
+// Components of a game server in the order they are deployed
+const (
+	componentConfigMap  = "ConfigMap"
+	componentPVC        = "PVC"
+	componentDeployment = "Deployment"
+	componentService    = "Service"
+)
+
+// A deployment failed because one of the components could not be created.
+// The components created before are deleted again, rollbackErrs holds the ones which could not be removed.
+type deploymentError struct {
+	uuid         string
+	component    string
+	err          error
+	rollbackErrs []error
+}
+
+func (e *deploymentError) Error() string {
+	msg := "Could not deploy " + e.component + ": " + e.err.Error()
+	for _, rollbackErr := range e.rollbackErrs {
+		msg += "; rollback failed: " + rollbackErr.Error()
+	}
+	return msg
+}
+
+func (e *deploymentError) Unwrap() error {
+	return e.err
+}
+
+// Deploy all components of a game server created by gameServerTemplate.GetUniqueGameServer, either all of them are created or none.
+// On failure the already created components are deleted and a *deploymentError is returned.
+func (k kubernetesClient) DeployGameServer(ctx context.Context, namespace string, deploymentPayload *gameServer) (*gameServer, error) {
 	createOptions := metav1.CreateOptions{}
+	// Deletes the created components in reverse order
+	rollback := []func(context.Context) error{}
+	fail := func(component string, err error) (*gameServer, error) {
+		deployErr := &deploymentError{uuid: deploymentPayload.GetUID(), component: component, err: err}
+		// The request may already be cancelled, the rollback has to run anyway
+		rollbackCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for i := len(rollback) - 1; i >= 0; i-- {
+			if err := rollback[i](rollbackCtx); err != nil && !apierrors.IsNotFound(err) {
+				deployErr.rollbackErrs = append(deployErr.rollbackErrs, err)
+			}
+		}
+		fmt.Println(deployErr.Error())
+		return nil, deployErr
+	}
+	deleteOptions := metav1.DeleteOptions{}
 	//Deploy ConfigMap
 	deployedConfigMap, err := k.Client.CoreV1().ConfigMaps(namespace).Create(ctx, &deploymentPayload.configmap.ConfigMap, createOptions)
 	if err != nil {
-		return nil, err
+		return fail(componentConfigMap, err)
 	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		return k.Client.CoreV1().ConfigMaps(namespace).Delete(ctx, deployedConfigMap.GetName(), deleteOptions)
+	})
 	fmt.Println("Deployed ConfigMap: " + deployedConfigMap.GetName())
 	//Deploy PersistentVolumeClaim
 	deployedPVC, err := k.Client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, &deploymentPayload.pvc.PersistentVolumeClaim, createOptions)
 	if err != nil {
-		return nil, err
+		return fail(componentPVC, err)
 	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		return k.Client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, deployedPVC.GetName(), deleteOptions)
+	})
 	fmt.Println("Deployed PVC: " + deployedPVC.GetName())
 	//Adapt Deployment with name references to the generated Names
 	payloadDeployment := deploymentPayload.deployment.Deployment
@@ -140,23 +206,38 @@ func (k kubernetesClient) DeployTemplate(ctx context.Context, namespace string,
 	}
 	//Replace reference to PVC
 	for _, volume := range payloadDeployment.Spec.Template.Spec.Volumes {
-		if volume.PersistentVolumeClaim.ClaimName == "GameServerTemplatePersistentVolumeClaim" {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == "GameServerTemplatePersistentVolumeClaim" {
 			volume.PersistentVolumeClaim.ClaimName = deployedPVC.GetName()
 			fmt.Println("Referenced PVC in Deployment: " + deployedPVC.GetName())
 		} else {
-			panic("payloadDeployment contains reference to Volume and not Templatestring")
+			return fail(componentDeployment, errors.New("Deployment references Volume "+volume.Name+" which is not the PVC of the template"))
 		}
 	}
 	//Deploy Deployment
 	deployedDeployment, err := k.Client.AppsV1().Deployments(namespace).Create(ctx, &payloadDeployment, createOptions)
 	if err != nil {
-		return nil, err
+		return fail(componentDeployment, err)
 	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		return k.Client.AppsV1().Deployments(namespace).Delete(ctx, deployedDeployment.GetName(), deleteOptions)
+	})
 	fmt.Println("Deployed Deployment: " + deployedDeployment.GetName())
+	//Make the Deployment the owner of the other components, they are garbage collected with it
+	owner := deploymentOwnerReference(deployedDeployment)
+	deployedConfigMap, err = k.setConfigMapOwner(ctx, namespace, deployedConfigMap.GetName(), owner)
+	if err != nil {
+		return fail(componentConfigMap, err)
+	}
+	deployedPVC, err = k.setPVCOwner(ctx, namespace, deployedPVC.GetName(), owner)
+	if err != nil {
+		return fail(componentPVC, err)
+	}
 	//Deploy Service
-	deployedService, err := k.Client.CoreV1().Services(namespace).Create(ctx, &deploymentPayload.service.Service, createOptions)
+	payloadService := deploymentPayload.service.Service
+	payloadService.OwnerReferences = append(payloadService.OwnerReferences, owner)
+	deployedService, err := k.Client.CoreV1().Services(namespace).Create(ctx, &payloadService, createOptions)
 	if err != nil {
-		return nil, err
+		return fail(componentService, err)
 	}
 	fmt.Println("Deployed Service: " + deployedService.GetName())
 	return &gameServer{
@@ -167,25 +248,117 @@ func (k kubernetesClient) DeployTemplate(ctx context.Context, namespace string,
 	}, nil
 }
 
-func (k kubernetesClient) DeleteGameserver(ctx context.Context, namespace string, target *gameServer) error {
-	deleteOptions := metav1.DeleteOptions{}
-	err := k.Client.CoreV1().ConfigMaps(namespace).Delete(ctx, target.configmap.Name, deleteOptions)
-	if err != nil {
-		return err
+// The Deployment owns the other components of a game server. It is not set as blocking owner,
+// which would require the permission to update the finalizers of Deployments.
+func deploymentOwnerReference(deployment *appsv1.Deployment) metav1.OwnerReference {
+	isController := true
+	return metav1.OwnerReference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       "Deployment",
+		Name:       deployment.GetName(),
+		UID:        deployment.GetUID(),
+		Controller: &isController,
 	}
-	err = k.Client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, target.pvc.Name, deleteOptions)
-	if err != nil {
-		return err
+}
+
+func isOwnedBy(object metav1.Object, owner metav1.Object) bool {
+	for _, reference := range object.GetOwnerReferences() {
+		if reference.UID == owner.GetUID() {
+			return true
+		}
 	}
-	err = k.Client.AppsV1().Deployments(namespace).Delete(ctx, target.deployment.Name, deleteOptions)
-	if err != nil {
-		return err
+	return false
+}
+
+func withoutOwner(references []metav1.OwnerReference, owner types.UID) []metav1.OwnerReference {
+	remaining := []metav1.OwnerReference{}
+	for _, reference := range references {
+		if reference.UID != owner {
+			remaining = append(remaining, reference)
+		}
 	}
-	err = k.Client.CoreV1().Services(namespace).Delete(ctx, target.service.Name, deleteOptions)
-	if err != nil {
+	return remaining
+}
+
+func (k kubernetesClient) setConfigMapOwner(ctx context.Context, namespace string, name string, owner metav1.OwnerReference) (*v1.ConfigMap, error) {
+	configMaps := k.Client.CoreV1().ConfigMaps(namespace)
+	var updated *v1.ConfigMap
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		configMap.OwnerReferences = append(withoutOwner(configMap.OwnerReferences, owner.UID), owner)
+		updated, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
 		return err
+	})
+	return updated, err
+}
+
+func (k kubernetesClient) setPVCOwner(ctx context.Context, namespace string, name string, owner metav1.OwnerReference) (*v1.PersistentVolumeClaim, error) {
+	pvcs := k.Client.CoreV1().PersistentVolumeClaims(namespace)
+	var updated *v1.PersistentVolumeClaim
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pvc, err := pvcs.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pvc.OwnerReferences = append(withoutOwner(pvc.OwnerReferences, owner.UID), owner)
+		updated, err = pvcs.Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+	return updated, err
+}
+
+// Detach the PVC from the Deployment so it survives the deletion of the game server.
+// The retained PVC is marked with the retainedLabel.
+func (k kubernetesClient) retainPVC(ctx context.Context, namespace string, target *gameServer) error {
+	pvcs := k.Client.CoreV1().PersistentVolumeClaims(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pvc, err := pvcs.Get(ctx, target.pvc.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pvc.OwnerReferences = withoutOwner(pvc.OwnerReferences, target.deployment.GetUID())
+		if pvc.Labels == nil {
+			pvc.Labels = map[string]string{}
+		}
+		pvc.Labels[retainedLabel] = "true"
+		_, err = pvcs.Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Delete the game server. The components owned by the Deployment are removed by the garbage collection of Kubernetes,
+// with keepData the PVC is retained. Components of game servers deployed before the ownerReferences
+// were introduced are deleted one by one.
+func (k kubernetesClient) DeleteGameserver(ctx context.Context, namespace string, target *gameServer, keepData bool) error {
+	if keepData {
+		if err := k.retainPVC(ctx, namespace, target); err != nil {
+			return err
+		}
 	}
-	return nil
+	deleteOptions := metav1.DeleteOptions{}
+	if !isOwnedBy(&target.configmap.ConfigMap, &target.deployment.Deployment) {
+		err := k.Client.CoreV1().ConfigMaps(namespace).Delete(ctx, target.configmap.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if !keepData && !isOwnedBy(&target.pvc.PersistentVolumeClaim, &target.deployment.Deployment) {
+		err := k.Client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, target.pvc.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if !isOwnedBy(&target.service.Service, &target.deployment.Deployment) {
+		err := k.Client.CoreV1().Services(namespace).Delete(ctx, target.service.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	propagation := metav1.DeletePropagationBackground
+	return k.Client.AppsV1().Deployments(namespace).Delete(ctx, target.deployment.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
 }
 
 func (k kubernetesClient) TestUpdateDeployedGameserver(ctx context.Context, namespace string, target *gameServer) (*gameServer, error) {
@@ -242,6 +415,64 @@ func (k kubernetesClient) Rescale(ctx context.Context, namespace string, target
 	return nil
 }
 
+// Find the namespace of a game server of another user which is shared with the member
+func (k kubernetesClient) FindSharedGameServer(ctx context.Context, uuid string, memberUuid string) (string, memberRole, error) {
+	if !isValidGameServerId(uuid) {
+		return "", "", errInvalidGameServerId
+	}
+	selector := "deploymentUUID=" + uuid + "," + memberLabel(memberUuid)
+	deployments, err := k.Client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", "", err
+	}
+	if len(deployments.Items) != 1 {
+		return "", "", errNotShared
+	}
+	deployment := deployments.Items[0]
+	return deployment.Namespace, memberRole(deployment.Labels[memberLabel(memberUuid)]), nil
+}
+
+// List the game servers of other users which are shared with the member
+func (k kubernetesClient) GetSharedGameServerList(ctx context.Context, memberUuid string) ([]*gameServer, error) {
+	deployments, err := k.Client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: memberLabel(memberUuid)})
+	if err != nil {
+		return nil, err
+	}
+	gameServers := make([]*gameServer, 0)
+	for _, deployment := range deployments.Items {
+		uuid := deployment.Labels["deploymentUUID"]
+		sharedGameServer, err := k.GetGameServer(ctx, deployment.Namespace, uuid)
+		if err != nil {
+			fmt.Println("Could not find complete shared GameServer for UUID:" + uuid)
+			fmt.Println(err)
+		} else {
+			gameServers = append(gameServers, sharedGameServer)
+		}
+	}
+	return gameServers, nil
+}
+
+// Grant the member access to the game server, an empty role removes the access
+func (k kubernetesClient) SetGameServerMember(ctx context.Context, namespace string, target *gameServer, memberUuid string, role memberRole) error {
+	deployments := k.Client.AppsV1().Deployments(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := deployments.Get(ctx, target.deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if role == "" {
+			delete(deployment.Labels, memberLabel(memberUuid))
+		} else {
+			if deployment.Labels == nil {
+				deployment.Labels = map[string]string{}
+			}
+			deployment.Labels[memberLabel(memberUuid)] = string(role)
+		}
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+		return err
+	})
+}
+
 func (k kubernetesClient) CreateDockerConfigSecret(ctx context.Context, namespace string, name string, base64secret string) (*v1.Secret, error) {
 	//base64secret = "{\"auths\": {\"url.to.server\": {\"auth\": \"base64=\"}}}"
 	secretMap := map[string]string{".dockerconfigjson": base64secret}
@@ -307,12 +538,9 @@ func (k kubernetesClient) SetUserSecret(ctx context.Context, email string, user
 
 	// new users might not have uuid so we need to generate one
 	if secret.Data == nil {
-		random := make([]byte, 4)
-		rand.Read(random)
-		id := uuidGen.NewV5(uuidGen.NameSpaceURL, "game-base.de/backend/user", email, time.Now().UTC(), random)
-		uuid := uuidGen.Formatter(id, uuidGen.FormatHex)
 		secret.Data = map[string][]byte{
-			"uuid": []byte(uuid),
+			"uuid":    []byte(newUserUuid(email)),
+			"created": []byte(formatCreationTime(secret.CreationTimestamp.Time)),
 		}
 	}
 
@@ -321,11 +549,49 @@ func (k kubernetesClient) SetUserSecret(ctx context.Context, email string, user
 			secret.Data[key] = []byte(value)
 		}
 	}
+	labelUserSecret(secret)
 
 	_, err = k.UpdateSecret(ctx, defaultNamespace, secret)
 	return err
 }
 
+// Apply the update to the user stored in the secret. Concurrent changes of the secret make
+// the update fail because of its resource version, it is retried with the current user then.
+func (k kubernetesClient) UpdateUserSecret(ctx context.Context, email string, update func(user *GamebaseUser) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := k.GetSecret(ctx, defaultNamespace, encodeEmail(email))
+		if err != nil {
+			return err
+		}
+		user := NewGamebaseUserFromSecretData(email, secret.Data)
+		if err := update(&user); err != nil {
+			return err
+		}
+
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for key, value := range user.ToSecretData() {
+			if value == "" {
+				delete(secret.Data, key)
+			} else {
+				secret.Data[key] = []byte(value)
+			}
+		}
+		labelUserSecret(secret)
+
+		_, err = k.UpdateSecret(ctx, defaultNamespace, secret)
+		return err
+	})
+}
+
+func labelUserSecret(secret *v1.Secret) {
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	secret.Labels[userUuidLabel] = string(secret.Data["uuid"])
+}
+
 // Lookup the uuid of the user
 func (k kubernetesClient) GetUuid(ctx context.Context, email string) (string, error) {
 	encoded := encodeEmail(email)
@@ -362,10 +628,310 @@ func (k kubernetesClient) GetUserSecret(ctx context.Context, email string) (*Gam
 	}
 
 	user := NewGamebaseUserFromSecretData(email, secret.Data)
+	if user.Created.IsZero() {
+		user.Created = secret.CreationTimestamp.Time
+	}
 	return &user, nil
 }
 
+// Lookup the user secret by the label of the uuid.
+// Fails with a NotFound error if there is no user secret with the uuid.
+func (k kubernetesClient) GetUserSecretByUuid(ctx context.Context, uuid string) (*GamebaseUser, error) {
+	notFound := apierrors.NewNotFound(v1.Resource("secrets"), uuid)
+	// the uuid is taken from tokens, it must not change the meaning of the selector
+	if uuid == "" || len(validation.IsValidLabelValue(uuid)) > 0 {
+		return nil, notFound
+	}
+
+	secrets, err := k.Client.CoreV1().Secrets(defaultNamespace).List(ctx, metav1.ListOptions{LabelSelector: userUuidLabel + "=" + uuid})
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		email, isUserSecret := tryDecodeEmail(secret.Name)
+		if !isUserSecret || string(secret.Data["uuid"]) != uuid {
+			continue
+		}
+		user := NewGamebaseUserFromSecretData(email, secret.Data)
+		if user.Created.IsZero() {
+			user.Created = secret.CreationTimestamp.Time
+		}
+		return &user, nil
+	}
+	return nil, notFound
+}
+
+// List the users of all user secrets in the default namespace
+func (k kubernetesClient) ListUserSecrets(ctx context.Context) ([]GamebaseUser, error) {
+	secrets, err := k.Client.CoreV1().Secrets(defaultNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	users := []GamebaseUser{}
+	for _, secret := range secrets.Items {
+		// other secrets like the JWT signing keys are stored in the same namespace
+		email, isUserSecret := tryDecodeEmail(secret.Name)
+		if _, hasUuid := secret.Data["uuid"]; !isUserSecret || !hasUuid || secret.Type != v1.SecretTypeOpaque {
+			continue
+		}
+		user := NewGamebaseUserFromSecretData(email, secret.Data)
+		if user.Created.IsZero() {
+			user.Created = secret.CreationTimestamp.Time
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// List the namespaces of all users
+func (k kubernetesClient) ListUserNamespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := k.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, namespace := range namespaces.Items {
+		if strings.HasPrefix(namespace.Name, defaultNamespaceUser) {
+			names = append(names, namespace.Name)
+		}
+	}
+	return names, nil
+}
+
+// Move the user secret to the name of the new email address.
+// Creating the new secret fails if the email is already taken, the new secret
+// is removed again if the old one cannot be deleted.
+func (k kubernetesClient) RenameUserSecret(ctx context.Context, email string, newEmail string) error {
+	secrets := k.Client.CoreV1().Secrets(defaultNamespace)
+	secret, err := secrets.Get(ctx, encodeEmail(email), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	renamed := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        encodeEmail(newEmail),
+			Labels:      secret.Labels,
+			Annotations: secret.Annotations,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+	if _, err := secrets.Create(ctx, renamed, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	// the resource version makes sure the old secret has not been changed meanwhile
+	deleteOptions := metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &secret.ResourceVersion}}
+	if err := secrets.Delete(ctx, secret.Name, deleteOptions); err != nil {
+		if rollbackErr := secrets.Delete(ctx, renamed.Name, metav1.DeleteOptions{}); rollbackErr != nil {
+			return fmt.Errorf("%v, rollback failed: %v", err, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove the given keys from the data of the user secret
+func (k kubernetesClient) ClearUserSecretFields(ctx context.Context, email string, fields []string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := k.GetSecret(ctx, defaultNamespace, encodeEmail(email))
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			delete(secret.Data, field)
+		}
+		_, err = k.UpdateSecret(ctx, defaultNamespace, secret)
+		return err
+	})
+}
+
 func (k kubernetesClient) DeleteUserSecret(ctx context.Context, email string) error {
 	deleteOptions := metav1.DeleteOptions{}
 	return k.Client.CoreV1().Secrets(defaultNamespace).Delete(ctx, encodeEmail(email), deleteOptions)
 }
+
+func (k kubernetesClient) DeleteNamespace(ctx context.Context, name string) error {
+	return k.Client.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// Check if the namespace exists, namespaces which are terminating still exist
+func (k kubernetesClient) NamespaceExists(ctx context.Context, name string) (bool, error) {
+	_, err := k.Client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Namespaces of users and organizations, which contain game servers
+func (k kubernetesClient) ListGameServerNamespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := k.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, namespace := range namespaces.Items {
+		if strings.HasPrefix(namespace.Name, defaultNamespaceUser) || strings.HasPrefix(namespace.Name, defaultNamespaceOrganization) {
+			names = append(names, namespace.Name)
+		}
+	}
+	return names, nil
+}
+
+// Group the components in the namespace by their deploymentUUID label.
+// Retained PVCs and components which are being deleted are skipped.
+func (k kubernetesClient) GetComponentGroups(ctx context.Context, namespace string) (map[string]*componentGroup, error) {
+	listOptions := metav1.ListOptions{LabelSelector: "deploymentUUID"}
+	groups := map[string]*componentGroup{}
+	group := func(object metav1.Object) *componentGroup {
+		uuid := object.GetLabels()["deploymentUUID"]
+		if groups[uuid] == nil {
+			groups[uuid] = &componentGroup{uuid: uuid, namespace: namespace}
+		}
+		return groups[uuid]
+	}
+
+	configMaps, err := k.Client.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, configMap := range configMaps.Items {
+		if configMap.DeletionTimestamp == nil {
+			g := group(&configMap)
+			g.configMaps = append(g.configMaps, configMap)
+		}
+	}
+	pvcs, err := k.Client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs.Items {
+		if pvc.DeletionTimestamp == nil && pvc.Labels[retainedLabel] != "true" {
+			g := group(&pvc)
+			g.pvcs = append(g.pvcs, pvc)
+		}
+	}
+	deployments, err := k.Client.AppsV1().Deployments(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		if deployment.DeletionTimestamp == nil {
+			g := group(&deployment)
+			g.deployments = append(g.deployments, deployment)
+		}
+	}
+	services, err := k.Client.CoreV1().Services(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services.Items {
+		if service.DeletionTimestamp == nil {
+			g := group(&service)
+			g.services = append(g.services, service)
+		}
+	}
+	return groups, nil
+}
+
+// Delete all components of the group
+func (k kubernetesClient) DeleteComponentGroup(ctx context.Context, group *componentGroup) error {
+	deleteOptions := metav1.DeleteOptions{}
+	propagation := metav1.DeletePropagationBackground
+	for _, deployment := range group.deployments {
+		err := k.Client.AppsV1().Deployments(group.namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, configMap := range group.configMaps {
+		err := k.Client.CoreV1().ConfigMaps(group.namespace).Delete(ctx, configMap.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, pvc := range group.pvcs {
+		err := k.Client.CoreV1().PersistentVolumeClaims(group.namespace).Delete(ctx, pvc.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, service := range group.services {
+		err := k.Client.CoreV1().Services(group.namespace).Delete(ctx, service.Name, deleteOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recreate the missing components of a group with a single Deployment from the template.
+// The ConfigMap and PVC get the names referenced by the Deployment, the recreated PVC is empty.
+func (k kubernetesClient) RepairComponentGroup(ctx context.Context, group *componentGroup, template *gameServerTemplate) error {
+	deployment := group.deployments[0]
+	owner := deploymentOwnerReference(&deployment)
+	createOptions := metav1.CreateOptions{}
+	if len(group.configMaps) == 0 {
+		configMap := template.configmap.DeeperCopy().ConfigMap
+		if name := referencedConfigMap(&deployment); name != "" {
+			configMap.Name, configMap.GenerateName = name, ""
+		}
+		configMap.Labels["deploymentUUID"] = group.uuid
+		configMap.OwnerReferences = []metav1.OwnerReference{owner}
+		created, err := k.Client.CoreV1().ConfigMaps(group.namespace).Create(ctx, &configMap, createOptions)
+		if err != nil {
+			return &deploymentError{uuid: group.uuid, component: componentConfigMap, err: err}
+		}
+		fmt.Println("Repaired ConfigMap: " + created.GetName())
+	}
+	if len(group.pvcs) == 0 {
+		pvc := template.pvc.DeeperCopy().PersistentVolumeClaim
+		if name := referencedPVC(&deployment); name != "" {
+			pvc.Name, pvc.GenerateName = name, ""
+		}
+		pvc.Labels["deploymentUUID"] = group.uuid
+		pvc.OwnerReferences = []metav1.OwnerReference{owner}
+		created, err := k.Client.CoreV1().PersistentVolumeClaims(group.namespace).Create(ctx, &pvc, createOptions)
+		if err != nil {
+			return &deploymentError{uuid: group.uuid, component: componentPVC, err: err}
+		}
+		fmt.Println("Repaired PVC: " + created.GetName())
+	}
+	if len(group.services) == 0 {
+		service := template.service.DeeperCopy().Service
+		service.Labels["deploymentUUID"] = group.uuid
+		service.Spec.Selector = deployment.Spec.Template.Labels
+		service.OwnerReferences = []metav1.OwnerReference{owner}
+		created, err := k.Client.CoreV1().Services(group.namespace).Create(ctx, &service, createOptions)
+		if err != nil {
+			return &deploymentError{uuid: group.uuid, component: componentService, err: err}
+		}
+		fmt.Println("Repaired Service: " + created.GetName())
+	}
+	return nil
+}
+
+// Name of the ConfigMap the containers of the Deployment take their environment from
+func referencedConfigMap(deployment *appsv1.Deployment) string {
+	spec := deployment.Spec.Template.Spec
+	for _, container := range append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...) {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				return envFrom.ConfigMapRef.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Name of the PVC mounted by the Deployment
+func referencedPVC(deployment *appsv1.Deployment) string {
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type AccountDeletion struct {
+
+	// ID used to query the progress of the deletion
+	Id string `json:"id"`
+
+	// Status of the deletion (running, completed or failed)
+	Status string `json:"status"`
+
+	// Reason why the deletion failed
+	Details string `json:"details,omitempty"`
+
+	// Cleanup steps in the order they are executed
+	Steps []AccountDeletionStep `json:"steps"`
+}
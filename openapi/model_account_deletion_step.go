@@ -0,0 +1,23 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type AccountDeletionStep struct {
+
+	// Name of the cleanup step
+	Name string `json:"name"`
+
+	// Status of the step (pending, running, completed or failed)
+	Status string `json:"status"`
+
+	// Progress or error message of the step
+	Details string `json:"details,omitempty"`
+}
@@ -0,0 +1,22 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type AdminGameContainerStatus struct {
+
+	// Email address of the owner, empty if the namespace does not belong to a known user
+	Owner string `json:"owner,omitempty"`
+
+	// Namespace the game server is deployed in
+	Namespace string `json:"namespace"`
+
+	Server GameContainerStatus `json:"server"`
+}
@@ -0,0 +1,33 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type ApiToken struct {
+
+	// ID of the token, used to revoke it
+	Id string `json:"id"`
+
+	// Name given to the token by the user
+	Name string `json:"name"`
+
+	// Scopes which restrict the endpoints the token can be used for
+	Scopes []string `json:"scopes"`
+
+	// Time of the creation
+	Created time.Time `json:"created"`
+
+	// Time when the token expires, never if unset
+	Expires *time.Time `json:"expires,omitempty"`
+}
@@ -0,0 +1,23 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type ApiTokenCreation struct {
+
+	// Name of the token, e.g. the script or CI job using it
+	Name string `json:"name"`
+
+	// Scopes granted to the token (gs:read, gs:control, gs:write, user:read)
+	Scopes []string `json:"scopes"`
+
+	// Days until the token expires, the token does not expire if unset or 0
+	ExpiresInDays int32 `json:"expiresInDays,omitempty"`
+}
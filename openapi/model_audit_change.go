@@ -0,0 +1,20 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type AuditChange struct {
+
+	// Value before the action, unset if the field was added
+	Old interface{} `json:"old,omitempty"`
+
+	// Value after the action, unset if the field was removed
+	New interface{} `json:"new,omitempty"`
+}
@@ -0,0 +1,57 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type AuditEvent struct {
+
+	// Unique id of the event
+	Id string `json:"id"`
+
+	// Time the action was performed
+	Time time.Time `json:"time"`
+
+	// Email address of the user who performed the action, empty if unknown
+	Actor string `json:"actor,omitempty"`
+
+	// Type of the token used to authenticate, e.g. access or api
+	TokenType string `json:"tokenType,omitempty"`
+
+	// IP address of the client
+	ClientIp string `json:"clientIp"`
+
+	// Name of the performed action, e.g. DeployContainer
+	Action string `json:"action"`
+
+	// UUID of the game server or email address of the user the action was performed on
+	Target string `json:"target,omitempty"`
+
+	// Namespace of the game server
+	Namespace string `json:"namespace,omitempty"`
+
+	// Request body with credentials removed
+	Request interface{} `json:"request,omitempty"`
+
+	// Changed fields by path with their old and new values
+	Changes map[string]AuditChange `json:"changes,omitempty"`
+
+	// Either success or failure
+	Outcome string `json:"outcome"`
+
+	// HTTP status code of the response
+	Status int `json:"status"`
+
+	// Error message of a failed action
+	Error string `json:"error,omitempty"`
+}
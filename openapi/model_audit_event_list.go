@@ -0,0 +1,26 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type AuditEventList struct {
+
+	// The requested page of events, newest first
+	Events []AuditEvent `json:"events"`
+
+	// Total number of matching events
+	Total int `json:"total"`
+
+	// Index of the first returned event
+	Offset int `json:"offset"`
+
+	// Maximum number of returned events
+	Limit int `json:"limit"`
+}
@@ -14,4 +14,7 @@ type GameContainerDeployment struct {
 
 	// Template path of backend directory that is going to be used for game container creation
 	TemplatePath string `json:"templatePath"`
+
+	// Initial configuration applied before the game server is created, resources.templatePath may replace templatePath
+	Configuration *GameContainerConfiguration `json:"configuration,omitempty"`
 }
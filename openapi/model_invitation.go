@@ -0,0 +1,36 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type Invitation struct {
+
+	// The code to be entered on registration
+	Code string `json:"code"`
+
+	// Email address of the user who created the invitation
+	CreatedBy string `json:"createdBy"`
+
+	// Time of the creation
+	Created time.Time `json:"created"`
+
+	// Time when the invitation expires
+	Expires time.Time `json:"expires"`
+
+	// How many users can register with the invitation
+	MaxUses int32 `json:"maxUses"`
+
+	// How many users have registered with the invitation
+	Uses int32 `json:"uses"`
+}
@@ -0,0 +1,20 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type InvitationCreation struct {
+
+	// How many users can register with the invitation (default 1)
+	MaxUses int32 `json:"maxUses,omitempty"`
+
+	// Days until the invitation expires (default 7)
+	ExpiresInDays int32 `json:"expiresInDays,omitempty"`
+}
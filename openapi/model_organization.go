@@ -0,0 +1,30 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type Organization struct {
+
+	// Unique id of the organization
+	Id string `json:"id"`
+
+	// Display name of the organization
+	Name string `json:"name"`
+
+	// Time of the creation
+	Created time.Time `json:"created"`
+
+	// Role of the user in the organization (owner, admin, operator or viewer)
+	Role string `json:"role"`
+}
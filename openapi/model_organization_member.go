@@ -0,0 +1,23 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type OrganizationMember struct {
+
+	// Email address of the user
+	Email string `json:"email"`
+
+	// Full name of the user
+	FullName string `json:"fullName,omitempty"`
+
+	// Role of the user in the organization (owner, admin, operator or viewer)
+	Role string `json:"role"`
+}
@@ -0,0 +1,26 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+
+import (
+	"time"
+)
+
+type OrphanedComponent struct {
+
+	// Kind of the component: ConfigMap, PVC, Deployment or Service
+	Kind string `json:"kind"`
+
+	Name string `json:"name"`
+
+	Created time.Time `json:"created"`
+}
@@ -0,0 +1,40 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+
+import (
+	"time"
+)
+
+type OrphanedGameServer struct {
+
+	// UUID shared by the components of the game server
+	Id string `json:"id"`
+
+	// Namespace the components are deployed in
+	Namespace string `json:"namespace"`
+
+	// The existing components
+	Components []OrphanedComponent `json:"components"`
+
+	// Kinds of the components which do not exist
+	Missing []string `json:"missing,omitempty"`
+
+	// Creation time of the newest component
+	Since time.Time `json:"since"`
+
+	// Whether the missing components can be recreated from the template of the Deployment
+	Repairable bool `json:"repairable"`
+
+	// What the sweeper does once the grace period has passed
+	Action string `json:"action"`
+}
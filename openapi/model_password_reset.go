@@ -0,0 +1,23 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type PasswordReset struct {
+
+	// The reset token sent by email
+	Token string `json:"token"`
+
+	// The new password
+	Password string `json:"password"`
+
+	// Confirmation of the new password
+	ConfirmPassword string `json:"confirmPassword"`
+}
@@ -0,0 +1,26 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type ResourceUsage struct {
+
+	// Number of deployed game servers
+	GameServers int `json:"gameServers"`
+
+	// Number of game servers which are not stopped
+	RunningGameServers int `json:"runningGameServers"`
+
+	// Sum of the memory limits of all game servers which are not stopped in bytes
+	Memory int64 `json:"memory"`
+
+	// Sum of the storage requested by all game servers in bytes
+	Storage int64 `json:"storage"`
+}
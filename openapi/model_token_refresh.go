@@ -0,0 +1,17 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type TokenRefresh struct {
+
+	// The refresh token returned by login, registration or a previous refresh
+	RefreshToken string `json:"refreshToken"`
+}
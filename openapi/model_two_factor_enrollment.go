@@ -0,0 +1,20 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type TwoFactorEnrollment struct {
+
+	// Base32 encoded TOTP secret for manual entry
+	Secret string `json:"secret"`
+
+	// otpauth URI to be shown as QR code
+	OtpauthUri string `json:"otpauthUri"`
+}
@@ -0,0 +1,23 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type TwoFactorLogin struct {
+
+	// The challenge token returned by the login
+	ChallengeToken string `json:"challengeToken"`
+
+	// Code of the authenticator app
+	Code string `json:"code,omitempty"`
+
+	// One of the recovery codes, alternatively to the code
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
@@ -20,4 +20,7 @@ type User struct {
 
 	// The JWT of the users session
 	Token string `json:"token"`
+
+	// The long-lived token used to obtain a new session JWT
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
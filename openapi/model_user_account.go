@@ -0,0 +1,29 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type UserAccount struct {
+
+	// Email address of the user
+	Email string `json:"email"`
+
+	// The full name of the user
+	FullName string `json:"fullName"`
+
+	// ID of the user which is used to name the user namespace
+	Uuid string `json:"uuid"`
+
+	// Role of the user (user, operator or admin)
+	Role string `json:"role"`
+
+	// Whether the user may log in (active or disabled)
+	Status string `json:"status"`
+}
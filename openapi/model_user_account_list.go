@@ -0,0 +1,26 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type UserAccountList struct {
+
+	// The requested page of users ordered by email
+	Users []UserAccount `json:"users"`
+
+	// Total number of users
+	Total int `json:"total"`
+
+	// Index of the first returned user
+	Offset int `json:"offset"`
+
+	// Maximum number of returned users
+	Limit int `json:"limit"`
+}
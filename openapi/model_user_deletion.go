@@ -0,0 +1,17 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type UserDeletion struct {
+
+	// Current password of the user to confirm the deletion. Users linked to the OIDC identity provider may omit it if they have logged in during the last 5 minutes
+	Password string `json:"password,omitempty"`
+}
@@ -0,0 +1,41 @@
+/*
+ * GameBase Communication API
+ *
+ * This is the REST API used as an communication layer between backend and frontend.
+ *
+ * API version: 2.3.0
+ * Contact: gamebase@gahr.dev
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type UserDetails struct {
+
+	// Email address of the user
+	Email string `json:"email"`
+
+	// The full name of the user
+	FullName string `json:"fullName"`
+
+	// E-mail address of the Gravatar to be used
+	Gravatar string `json:"gravatar,omitempty"`
+
+	// ID of the user which is used to name the user namespace
+	Uuid string `json:"uuid"`
+
+	// Time of the registration
+	Created time.Time `json:"created"`
+
+	// Role of the user (user, operator or admin)
+	Role string `json:"role"`
+
+	// Whether two-factor authentication is enabled
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
+
+	Usage ResourceUsage `json:"usage"`
+}
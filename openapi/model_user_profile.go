@@ -15,7 +15,7 @@ type UserProfile struct {
 	// Username of the user
 	Username string `json:"username,omitempty"`
 
-	// E-mail address of the user
+	// New e-mail address of the user, it is changed once the link sent to the new address has been opened
 	Email string `json:"email,omitempty"`
 
 	Password UserProfilePassword `json:"password,omitempty"`
@@ -23,4 +23,7 @@ type UserRegister struct {
 
 	// The password confirmation of the user (must be equal to password)
 	ConfirmPassword string `json:"confirmPassword"`
+
+	// Invitation code, required if registration is restricted to invited users
+	InvitationCode string `json:"invitationCode,omitempty"`
 }
@@ -0,0 +1,377 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	uuidGen "github.com/twinj/uuid"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	organizationConfigMap        = "gamebase-organizations"
+	defaultNamespaceOrganization = defaultNamespace + "-org-"
+	// selects the organization for the /gs endpoints, alternatively to the path prefix /orgs/:org
+	organizationHeader = "X-Gamebase-Organization"
+
+	maxOrganizationNameLength    = 64
+	maxOwnedOrganizationsPerUser = 10
+)
+
+var (
+	errOrganizationNotFound  = errors.New("organization does not exist")
+	errNotOrganizationMember = errors.New("user is not a member of the organization")
+	errLastOwner             = errors.New("an organization needs at least one owner")
+)
+
+// set up on creation of the httpRequestAuthenticator
+var organizations organizationStore
+
+// An organization owns the game servers in its namespace.
+// The members get the same access to all of them, the roles are the same as for shared game servers.
+type organization struct {
+	Id      string                `json:"id"`
+	Name    string                `json:"name"`
+	Created time.Time             `json:"created"`
+	Members map[string]memberRole `json:"members"` // by user uuid
+}
+
+func newOrganization(name string, ownerUuid string) organization {
+	return organization{
+		Id:      uuidGen.NewV4().String(),
+		Name:    name,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Members: map[string]memberRole{ownerUuid: memberOwner},
+	}
+}
+
+func (o organization) namespace() string {
+	return defaultNamespaceOrganization + o.Id
+}
+
+func (o organization) countOwners() int {
+	owners := 0
+	for _, role := range o.Members {
+		if role == memberOwner {
+			owners++
+		}
+	}
+	return owners
+}
+
+// Set the role of the member, an empty role removes the member.
+// Fails with errLastOwner if the organization would be left without owner.
+func (o *organization) setMember(uuid string, role memberRole) error {
+	if o.Members[uuid] == memberOwner && role != memberOwner && o.countOwners() == 1 {
+		return errLastOwner
+	}
+	if role == "" {
+		delete(o.Members, uuid)
+	} else {
+		o.Members[uuid] = role
+	}
+	return nil
+}
+
+// Make the remaining members with the highest role owners if the last owner is gone
+func (o *organization) promoteOwners() {
+	if o.countOwners() > 0 {
+		return
+	}
+	highest := memberRole("")
+	for _, role := range o.Members {
+		if role.level() > highest.level() {
+			highest = role
+		}
+	}
+	for uuid, role := range o.Members {
+		if role == highest {
+			o.Members[uuid] = memberOwner
+		}
+	}
+}
+
+// construct the Organization returned by the organization endpoints
+func (o organization) toOrganization(role memberRole) Organization {
+	return Organization{
+		Id:      o.Id,
+		Name:    o.Name,
+		Created: o.Created,
+		Role:    string(role),
+	}
+}
+
+// The organization selected by the path segment /orgs/:org or the organization header, empty if none
+func organizationId(c *gin.Context) string {
+	if id := c.Param("org"); id != "" {
+		return id
+	}
+	return c.GetHeader(organizationHeader)
+}
+
+// Store for the organizations
+type organizationStore interface {
+	Create(ctx context.Context, org organization) error
+	// Fails with errOrganizationNotFound if the organization does not exist
+	Get(ctx context.Context, id string) (*organization, error)
+	// All organizations sorted by creation time
+	List(ctx context.Context) ([]organization, error)
+	// Apply the change to the organization, nothing is stored if the change fails
+	Update(ctx context.Context, id string, change func(*organization) error) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Select the organization store based on the ORGANIZATION_STORE environment variable
+func newOrganizationStore(k kubernetesClient) organizationStore {
+	switch store := os.Getenv("ORGANIZATION_STORE"); store {
+	case "", "kubernetes":
+		return &kubernetesOrganizationStore{k: k}
+	case "memory":
+		return &memoryOrganizationStore{organizations: map[string]organization{}}
+	default:
+		panic("Unknown ORGANIZATION_STORE " + store)
+	}
+}
+
+func sortOrganizations(list []organization) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+}
+
+// copy the members so changes of a failed update are not visible
+func (o organization) clone() organization {
+	members := make(map[string]memberRole, len(o.Members))
+	for uuid, role := range o.Members {
+		members[uuid] = role
+	}
+	o.Members = members
+	return o
+}
+
+// In-memory organization store. Organizations are lost on restart and not shared between replicas.
+type memoryOrganizationStore struct {
+	mutex         sync.Mutex
+	organizations map[string]organization
+}
+
+func (s *memoryOrganizationStore) Create(ctx context.Context, org organization) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.organizations[org.Id] = org.clone()
+	return nil
+}
+
+func (s *memoryOrganizationStore) Get(ctx context.Context, id string) (*organization, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	org, exists := s.organizations[id]
+	if !exists {
+		return nil, errOrganizationNotFound
+	}
+	org = org.clone()
+	return &org, nil
+}
+
+func (s *memoryOrganizationStore) List(ctx context.Context) ([]organization, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := []organization{}
+	for _, org := range s.organizations {
+		list = append(list, org.clone())
+	}
+	sortOrganizations(list)
+	return list, nil
+}
+
+func (s *memoryOrganizationStore) Update(ctx context.Context, id string, change func(*organization) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	org, exists := s.organizations[id]
+	if !exists {
+		return errOrganizationNotFound
+	}
+	org = org.clone()
+	if err := change(&org); err != nil {
+		return err
+	}
+	s.organizations[id] = org
+	return nil
+}
+
+func (s *memoryOrganizationStore) Delete(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.organizations[id]; !exists {
+		return errOrganizationNotFound
+	}
+	delete(s.organizations, id)
+	return nil
+}
+
+// Organization store persisted in a ConfigMap, the key is the id and the value the organization as JSON
+type kubernetesOrganizationStore struct {
+	k kubernetesClient
+}
+
+func (s *kubernetesOrganizationStore) Create(ctx context.Context, org organization) error {
+	return s.update(ctx, func(data map[string]organization) error {
+		data[org.Id] = org
+		return nil
+	})
+}
+
+func (s *kubernetesOrganizationStore) Get(ctx context.Context, id string) (*organization, error) {
+	list, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range list {
+		if org.Id == id {
+			return &org, nil
+		}
+	}
+	return nil, errOrganizationNotFound
+}
+
+func (s *kubernetesOrganizationStore) List(ctx context.Context) ([]organization, error) {
+	configMap, err := s.k.Client.CoreV1().ConfigMaps(defaultNamespace).Get(ctx, organizationConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []organization{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := []organization{}
+	for _, org := range decodeOrganizations(configMap) {
+		list = append(list, org)
+	}
+	sortOrganizations(list)
+	return list, nil
+}
+
+func (s *kubernetesOrganizationStore) Update(ctx context.Context, id string, change func(*organization) error) error {
+	return s.update(ctx, func(data map[string]organization) error {
+		org, exists := data[id]
+		if !exists {
+			return errOrganizationNotFound
+		}
+		if err := change(&org); err != nil {
+			return err
+		}
+		data[id] = org
+		return nil
+	})
+}
+
+func (s *kubernetesOrganizationStore) Delete(ctx context.Context, id string) error {
+	return s.update(ctx, func(data map[string]organization) error {
+		if _, exists := data[id]; !exists {
+			return errOrganizationNotFound
+		}
+		delete(data, id)
+		return nil
+	})
+}
+
+// Apply the change to the organizations in the ConfigMap
+func (s *kubernetesOrganizationStore) update(ctx context.Context, change func(map[string]organization) error) error {
+	configMaps := s.k.Client.CoreV1().ConfigMaps(defaultNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, organizationConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap, err = configMaps.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: organizationConfigMap},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		data := decodeOrganizations(configMap)
+		if err := change(data); err != nil {
+			return err
+		}
+
+		configMap.Data = map[string]string{}
+		for id, org := range data {
+			encoded, err := json.Marshal(org)
+			if err != nil {
+				return err
+			}
+			configMap.Data[id] = string(encoded)
+		}
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func decodeOrganizations(configMap *v1.ConfigMap) map[string]organization {
+	data := map[string]organization{}
+	for id, value := range configMap.Data {
+		var org organization
+		if err := json.Unmarshal([]byte(value), &org); err != nil {
+			fmt.Println("Skipping invalid organization " + id + ": " + err.Error())
+			continue
+		}
+		if org.Members == nil {
+			org.Members = map[string]memberRole{}
+		}
+		data[id] = org
+	}
+	return data
+}
+
+// Remove the user from all organizations. Organizations without members are deleted together with
+// their namespace, organizations without owner are handed over to the remaining members with the highest role.
+func leaveOrganizations(ctx context.Context, cl kubernetesClient, uuid string) (int, error) {
+	list, err := organizations.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	left := 0
+	for _, org := range list {
+		if _, isMember := org.Members[uuid]; !isMember {
+			continue
+		}
+		if len(org.Members) == 1 {
+			if err := cl.DeleteNamespace(ctx, org.namespace()); err != nil && !apierrors.IsNotFound(err) {
+				return left, err
+			}
+			if err := organizations.Delete(ctx, org.Id); err != nil && err != errOrganizationNotFound {
+				return left, err
+			}
+		} else {
+			err := organizations.Update(ctx, org.Id, func(o *organization) error {
+				delete(o.Members, uuid)
+				o.promoteOwners()
+				return nil
+			})
+			if err != nil && err != errOrganizationNotFound {
+				return left, err
+			}
+		}
+		left++
+	}
+	return left, nil
+}
+
+// construct the OrganizationMember returned by the organization member endpoints
+func (user GamebaseUser) toOrganizationMember(role memberRole) OrganizationMember {
+	return OrganizationMember{
+		Email:    user.Email,
+		FullName: user.Name,
+		Role:     string(role),
+	}
+}
@@ -0,0 +1,246 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// What the sweeper does with orphaned game servers after the grace period
+const (
+	orphanActionReport = "report"
+	orphanActionDelete = "delete"
+	orphanActionRepair = "repair"
+)
+
+const (
+	defaultOrphanSweepInterval = 10 * time.Minute
+	defaultOrphanGracePeriod   = time.Hour
+)
+
+// The components sharing a deploymentUUID label. A game server is complete if there is exactly one of each.
+type componentGroup struct {
+	uuid        string
+	namespace   string
+	configMaps  []v1.ConfigMap
+	pvcs        []v1.PersistentVolumeClaim
+	deployments []appsv1.Deployment
+	services    []v1.Service
+}
+
+func (g *componentGroup) counts() map[string]int {
+	return map[string]int{
+		componentConfigMap:  len(g.configMaps),
+		componentPVC:        len(g.pvcs),
+		componentDeployment: len(g.deployments),
+		componentService:    len(g.services),
+	}
+}
+
+func (g *componentGroup) isComplete() bool {
+	for _, count := range g.counts() {
+		if count != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *componentGroup) missing() []string {
+	missing := []string{}
+	counts := g.counts()
+	for _, component := range []string{componentConfigMap, componentPVC, componentDeployment, componentService} {
+		if counts[component] == 0 {
+			missing = append(missing, component)
+		}
+	}
+	return missing
+}
+
+// Only missing components can be repaired, the Deployment holds the configuration of the game server
+func (g *componentGroup) isRepairable(templates []*gameServerTemplate) bool {
+	if len(g.deployments) != 1 || len(g.configMaps) > 1 || len(g.pvcs) > 1 || len(g.services) > 1 {
+		return false
+	}
+	_, err := findGameServerTemplate(g.deployments[0].Labels["gameserver"], templates)
+	return err == nil
+}
+
+func (g *componentGroup) components() []OrphanedComponent {
+	components := []OrphanedComponent{}
+	for _, configMap := range g.configMaps {
+		components = append(components, OrphanedComponent{Kind: componentConfigMap, Name: configMap.Name, Created: configMap.CreationTimestamp.Time})
+	}
+	for _, pvc := range g.pvcs {
+		components = append(components, OrphanedComponent{Kind: componentPVC, Name: pvc.Name, Created: pvc.CreationTimestamp.Time})
+	}
+	for _, deployment := range g.deployments {
+		components = append(components, OrphanedComponent{Kind: componentDeployment, Name: deployment.Name, Created: deployment.CreationTimestamp.Time})
+	}
+	for _, service := range g.services {
+		components = append(components, OrphanedComponent{Kind: componentService, Name: service.Name, Created: service.CreationTimestamp.Time})
+	}
+	return components
+}
+
+// Creation time of the newest component, the grace period starts then
+func (g *componentGroup) since() time.Time {
+	since := time.Time{}
+	for _, component := range g.components() {
+		if component.Created.After(since) {
+			since = component.Created
+		}
+	}
+	return since
+}
+
+// Finds game servers with missing or duplicate components, e.g. left behind by a failed deployment or deletion.
+// Such game servers are not listed in /gs/status but still consume storage and NodePorts.
+type orphanSweeper struct {
+	cl          kubernetesClient
+	templates   []*gameServerTemplate
+	action      string
+	gracePeriod time.Duration
+}
+
+// The sweeper is configured by ORPHAN_ACTION, ORPHAN_GRACE_PERIOD and ORPHAN_SWEEP_INTERVAL.
+// An interval of 0 disables the periodic sweep, orphans are then only reported on request.
+func newOrphanSweeper(cl kubernetesClient, templates []*gameServerTemplate) *orphanSweeper {
+	sweeper := &orphanSweeper{
+		cl:          cl,
+		templates:   templates,
+		action:      orphanActionReport,
+		gracePeriod: defaultOrphanGracePeriod,
+	}
+	switch action := os.Getenv("ORPHAN_ACTION"); action {
+	case "":
+	case orphanActionReport, orphanActionDelete, orphanActionRepair:
+		sweeper.action = action
+	default:
+		panic("Unknown ORPHAN_ACTION " + action)
+	}
+	if value := os.Getenv("ORPHAN_GRACE_PERIOD"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			panic("Invalid ORPHAN_GRACE_PERIOD " + value)
+		}
+		sweeper.gracePeriod = parsed
+	}
+	interval := defaultOrphanSweepInterval
+	if value := os.Getenv("ORPHAN_SWEEP_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			panic("Invalid ORPHAN_SWEEP_INTERVAL " + value)
+		}
+		interval = parsed
+	}
+	if interval > 0 {
+		go sweeper.sweepPeriodically(interval)
+	}
+	return sweeper
+}
+
+// The action taken for the group after the grace period
+func (s *orphanSweeper) actionFor(group *componentGroup) string {
+	if s.action == orphanActionRepair && !group.isRepairable(s.templates) {
+		return orphanActionReport
+	}
+	return s.action
+}
+
+// All incomplete component groups in the namespaces of users and organizations
+func (s *orphanSweeper) findGroups(ctx context.Context) ([]*componentGroup, error) {
+	namespaces, err := s.cl.ListGameServerNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orphaned := []*componentGroup{}
+	for _, namespace := range namespaces {
+		groups, err := s.cl.GetComponentGroups(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if !group.isComplete() {
+				orphaned = append(orphaned, group)
+			}
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool {
+		if orphaned[i].namespace != orphaned[j].namespace {
+			return orphaned[i].namespace < orphaned[j].namespace
+		}
+		return orphaned[i].since().Before(orphaned[j].since())
+	})
+	return orphaned, nil
+}
+
+// Report the orphaned game servers without changing them
+func (s *orphanSweeper) Find(ctx context.Context) ([]OrphanedGameServer, error) {
+	groups, err := s.findGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orphans := []OrphanedGameServer{}
+	for _, group := range groups {
+		orphans = append(orphans, OrphanedGameServer{
+			Id:         group.uuid,
+			Namespace:  group.namespace,
+			Components: group.components(),
+			Missing:    group.missing(),
+			Since:      group.since(),
+			Repairable: group.isRepairable(s.templates),
+			Action:     s.actionFor(group),
+		})
+	}
+	return orphans, nil
+}
+
+// Delete or repair the orphaned game servers whose grace period has passed, depending on the action
+func (s *orphanSweeper) Sweep(ctx context.Context) error {
+	groups, err := s.findGroups(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, group := range groups {
+		if now.Sub(group.since()) < s.gracePeriod {
+			continue
+		}
+		description := "orphaned game server " + group.uuid + " in " + group.namespace + " missing [" + strings.Join(group.missing(), ", ") + "]"
+		switch s.actionFor(group) {
+		case orphanActionDelete:
+			if err := s.cl.DeleteComponentGroup(ctx, group); err != nil {
+				fmt.Println("Could not delete " + description + ": " + err.Error())
+				continue
+			}
+			fmt.Println("Deleted " + description)
+		case orphanActionRepair:
+			template, _ := findGameServerTemplate(group.deployments[0].Labels["gameserver"], s.templates)
+			if err := s.cl.RepairComponentGroup(ctx, group, template); err != nil {
+				fmt.Println("Could not repair " + description + ": " + err.Error())
+				continue
+			}
+			fmt.Println("Repaired " + description)
+		default:
+			fmt.Println("Found " + description)
+		}
+	}
+	return nil
+}
+
+func (s *orphanSweeper) sweepPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := s.Sweep(ctx); err != nil {
+			fmt.Println("Sweeping orphaned game servers failed: " + err.Error())
+		}
+		cancel()
+	}
+}
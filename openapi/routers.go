@@ -12,6 +12,7 @@ package openapi
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -37,8 +38,24 @@ func NewRouter() *gin.Engine {
 	router := gin.Default()
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
-	corsConfig.AddAllowHeaders("Authorization")
+	corsConfig.AddAllowHeaders("Authorization", organizationHeader)
 	router.Use(cors.New(corsConfig))
+	addRoutes(router, routes)
+	addRoutes(router, organizationRoutes(routes))
+
+	// The router of gin does not allow the wildcard in /gs/:id next to the static /gs routes.
+	// Requests without a static route are passed to a second router serving the routes below /gs/:id.
+	gameServerRouter := gin.New()
+	addRoutes(gameServerRouter, gameServerRoutes)
+	addRoutes(gameServerRouter, organizationRoutes(gameServerRoutes))
+	router.NoRoute(func(c *gin.Context) {
+		gameServerRouter.HandleContext(c)
+	})
+
+	return router
+}
+
+func addRoutes(router *gin.Engine, routes Routes) {
 	for _, route := range routes {
 		switch route.Method {
 		case http.MethodGet:
@@ -51,8 +68,18 @@ func NewRouter() *gin.Engine {
 			router.DELETE(route.Pattern, route.HandlerFunc)
 		}
 	}
+}
 
-	return router
+// The /gs routes prefixed with /orgs/:org to target the game servers of an organization
+func organizationRoutes(routes Routes) Routes {
+	prefixed := Routes{}
+	for _, route := range routes {
+		if strings.HasPrefix(route.Pattern, "/gs/") {
+			route.Pattern = "/orgs/:org" + route.Pattern
+			prefixed = append(prefixed, route)
+		}
+	}
+	return prefixed
 }
 
 // Index is the index handler.
@@ -68,6 +95,83 @@ var routes = Routes{
 		Index,
 	},
 
+	{
+		"AdminListGameServers",
+		http.MethodGet,
+		"/admin/gs",
+		AdminListGameServers,
+	},
+
+	{
+		"AdminListOrphanedGameServers",
+		http.MethodGet,
+		"/admin/gs/orphans",
+		AdminListOrphanedGameServers,
+	},
+
+	{
+		"AdminListUsers",
+		http.MethodGet,
+		"/admin/users",
+		AdminListUsers,
+	},
+
+	{
+		"AdminGetUser",
+		http.MethodGet,
+		"/admin/users/:email",
+		AdminGetUser,
+	},
+
+	{
+		"AdminSetUserRole",
+		http.MethodPut,
+		"/admin/users/:email/role",
+		AdminSetUserRole,
+	},
+
+	{
+		"AdminDisableUser",
+		http.MethodPost,
+		"/admin/users/:email/disable",
+		AdminDisableUser,
+	},
+
+	{
+		"AdminEnableUser",
+		http.MethodPost,
+		"/admin/users/:email/enable",
+		AdminEnableUser,
+	},
+
+	{
+		"AdminResetUserPassword",
+		http.MethodPost,
+		"/admin/users/:email/reset-password",
+		AdminResetUserPassword,
+	},
+
+	{
+		"AdminUnlockUser",
+		http.MethodPost,
+		"/admin/users/:email/unlock",
+		AdminUnlockUser,
+	},
+
+	{
+		"AdminDeleteUser",
+		http.MethodDelete,
+		"/admin/users/:email",
+		AdminDeleteUser,
+	},
+
+	{
+		"AuthJwksGet",
+		http.MethodGet,
+		"/.well-known/jwks.json",
+		AuthJwksGet,
+	},
+
 	{
 		"AuthLoginPost",
 		http.MethodPost,
@@ -82,6 +186,13 @@ var routes = Routes{
 		AuthLogoutDelete,
 	},
 
+	{
+		"AuthRefreshPost",
+		http.MethodPost,
+		"/auth/refresh",
+		AuthRefreshPost,
+	},
+
 	{
 		"AuthRegisterPost",
 		http.MethodPost,
@@ -89,6 +200,48 @@ var routes = Routes{
 		AuthRegisterPost,
 	},
 
+	{
+		"Auth2faPost",
+		http.MethodPost,
+		"/auth/2fa",
+		Auth2faPost,
+	},
+
+	{
+		"AuthForgotPost",
+		http.MethodPost,
+		"/auth/forgot",
+		AuthForgotPost,
+	},
+
+	{
+		"AuthResetPost",
+		http.MethodPost,
+		"/auth/reset",
+		AuthResetPost,
+	},
+
+	{
+		"AuthOidcLoginGet",
+		http.MethodGet,
+		"/auth/oidc/login",
+		AuthOidcLoginGet,
+	},
+
+	{
+		"AuthOidcCallbackGet",
+		http.MethodGet,
+		"/auth/oidc/callback",
+		AuthOidcCallbackGet,
+	},
+
+	{
+		"AuthVerifyGet",
+		http.MethodGet,
+		"/auth/verify",
+		AuthVerifyGet,
+	},
+
 	{
 		"ConfigureContainer",
 		http.MethodPost,
@@ -117,6 +270,13 @@ var routes = Routes{
 		GetStatus,
 	},
 
+	{
+		"GetStatus",
+		http.MethodGet,
+		"/gs/status/:id",
+		GetStatus,
+	},
+
 	{
 		"ListTemplates",
 		http.MethodGet,
@@ -145,6 +305,139 @@ var routes = Routes{
 		StopContainer,
 	},
 
+	{
+		"DisableTwoFactor",
+		http.MethodDelete,
+		"/user/2fa",
+		DisableTwoFactor,
+	},
+
+	{
+		"EnrollTwoFactor",
+		http.MethodPost,
+		"/user/2fa/enroll",
+		EnrollTwoFactor,
+	},
+
+	{
+		"ConfirmTwoFactor",
+		http.MethodPost,
+		"/user/2fa/confirm",
+		ConfirmTwoFactor,
+	},
+
+	{
+		"DeleteUser",
+		http.MethodDelete,
+		"/user",
+		DeleteUser,
+	},
+
+	{
+		"GetAccountDeletion",
+		http.MethodGet,
+		"/user/deletion/:id",
+		GetAccountDeletion,
+	},
+
+	{
+		"GetUserProfile",
+		http.MethodGet,
+		"/user/profile",
+		GetUserProfile,
+	},
+
+	{
+		"ListInvitations",
+		http.MethodGet,
+		"/invitations",
+		ListInvitations,
+	},
+
+	{
+		"CreateInvitation",
+		http.MethodPost,
+		"/invitations",
+		CreateInvitation,
+	},
+
+	{
+		"DeleteInvitation",
+		http.MethodDelete,
+		"/invitations/:code",
+		DeleteInvitation,
+	},
+
+	{
+		"ListOrganizations",
+		http.MethodGet,
+		"/orgs",
+		ListOrganizations,
+	},
+
+	{
+		"CreateOrganization",
+		http.MethodPost,
+		"/orgs",
+		CreateOrganization,
+	},
+
+	{
+		"DeleteOrganization",
+		http.MethodDelete,
+		"/orgs/:org",
+		DeleteOrganization,
+	},
+
+	{
+		"ListOrganizationMembers",
+		http.MethodGet,
+		"/orgs/:org/members",
+		ListOrganizationMembers,
+	},
+
+	{
+		"SetOrganizationMember",
+		http.MethodPut,
+		"/orgs/:org/members/:email",
+		SetOrganizationMember,
+	},
+
+	{
+		"RemoveOrganizationMember",
+		http.MethodDelete,
+		"/orgs/:org/members/:email",
+		RemoveOrganizationMember,
+	},
+
+	{
+		"ListAuditEvents",
+		http.MethodGet,
+		"/audit",
+		ListAuditEvents,
+	},
+
+	{
+		"ListApiTokens",
+		http.MethodGet,
+		"/user/tokens",
+		ListApiTokens,
+	},
+
+	{
+		"CreateApiToken",
+		http.MethodPost,
+		"/user/tokens",
+		CreateApiToken,
+	},
+
+	{
+		"RevokeApiToken",
+		http.MethodDelete,
+		"/user/tokens/:id",
+		RevokeApiToken,
+	},
+
 	{
 		"UpdateUserProfile",
 		http.MethodPost,
@@ -152,3 +445,34 @@ var routes = Routes{
 		UpdateUserProfile,
 	},
 }
+
+// Routes below /gs/:id, see NewRouter
+var gameServerRoutes = Routes{
+	{
+		"GetStatus",
+		http.MethodGet,
+		"/gs/:id",
+		GetStatus,
+	},
+
+	{
+		"ListGameServerMembers",
+		http.MethodGet,
+		"/gs/:id/members",
+		ListGameServerMembers,
+	},
+
+	{
+		"SetGameServerMember",
+		http.MethodPut,
+		"/gs/:id/members/:email",
+		SetGameServerMember,
+	},
+
+	{
+		"RemoveGameServerMember",
+		http.MethodDelete,
+		"/gs/:id/members/:email",
+		RemoveGameServerMember,
+	},
+}
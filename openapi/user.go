@@ -2,34 +2,177 @@ package openapi
 
 import (
 	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type GamebaseUser struct {
+	Uuid     string
 	Name     string
 	Email    string
-	Password string
+	Password string // encoded password hash, see hashPassword
 	Gravatar string
+	Role     userRole
+	Status   userStatus
+	Created  time.Time
+	// two-factor authentication, see authentication_totp.go
+	TotpSecret    string   // confirmed secret, 2FA is enabled if set
+	TotpPending   string   // secret of an enrollment which has not been confirmed yet
+	TotpCounter   int64    // time step of the last accepted code, prevents replays
+	RecoveryCodes []string // hashes of the unused recovery codes
+	// subject of the linked account at the OIDC identity provider, see authentication_oidc.go
+	OidcSubject string
+	// personal access tokens, see authentication_api_token.go
+	ApiTokens []apiToken
+	// when the user has been granted the admin role because of ADMIN_EMAILS, see promoteConfiguredAdmin
+	AdminPromoted time.Time
+}
+
+// Whether the user is allowed to log in
+type userStatus string
+
+const (
+	userActive   userStatus = "active"
+	userDisabled userStatus = "disabled"
+	// registered but the email address has not been verified yet
+	userPending userStatus = "pending"
+)
+
+// users created before the status was introduced are active
+func (status userStatus) orDefault() userStatus {
+	if status == "" {
+		return userActive
+	}
+	return status
+}
+
+// Reason why the user must not log in, empty for active users
+func (status userStatus) loginDenial() string {
+	switch status.orDefault() {
+	case userActive:
+		return ""
+	case userPending:
+		return "email address not verified"
+	default:
+		return "account disabled"
+	}
 }
 
 // construct a GamebaseUser from the data field of a v1 secret
 func NewGamebaseUserFromSecretData(email string, data map[string][]byte) GamebaseUser {
+	uuid := string(data["uuid"])
 	name := string(data["name"])
 	password := string(data["password"])
 	gravatar := string(data["gravatar"])
+	role := userRole(data["role"])
+	status := userStatus(data["status"])
+	// zero for users created before the creation time was recorded
+	created, _ := time.Parse(time.RFC3339, string(data["created"]))
+	adminPromoted, _ := time.Parse(time.RFC3339, string(data["admin_promoted"]))
+	totpCounter, _ := strconv.ParseInt(string(data["totp_counter"]), 10, 64)
+	var apiTokens []apiToken
+	if tokens := data["api_tokens"]; len(tokens) > 0 {
+		// tokens which cannot be decoded are dropped and therefore invalid
+		_ = json.Unmarshal(tokens, &apiTokens)
+	}
+	var recoveryCodes []string
+	if codes := string(data["recovery_codes"]); codes != "" {
+		recoveryCodes = strings.Split(codes, ",")
+	}
 
 	return GamebaseUser{
+		Uuid:     uuid,
 		Name:     name,
 		Email:    email,
 		Password: password,
 		Gravatar: gravatar,
+		Role:     role,
+		Status:   status,
+		Created:  created,
+
+		TotpSecret:    string(data["totp_secret"]),
+		TotpPending:   string(data["totp_pending"]),
+		TotpCounter:   totpCounter,
+		RecoveryCodes: recoveryCodes,
+
+		OidcSubject: string(data["oidc_subject"]),
+		ApiTokens:   apiTokens,
+
+		AdminPromoted: adminPromoted,
 	}
 }
 
 func (user GamebaseUser) ToSecretData() map[string]string {
 	return map[string]string{
+		"uuid":     user.Uuid,
 		"name":     user.Name,
 		"password": user.Password,
 		"gravatar": user.Gravatar,
+		"role":     string(user.Role),
+		"status":   string(user.Status),
+		"created":  formatCreationTime(user.Created),
+
+		"totp_secret":    user.TotpSecret,
+		"totp_pending":   user.TotpPending,
+		"totp_counter":   formatTotpCounter(user.TotpCounter),
+		"recovery_codes": strings.Join(user.RecoveryCodes, ","),
+
+		"oidc_subject": user.OidcSubject,
+		"api_tokens":   formatApiTokens(user.ApiTokens),
+
+		"admin_promoted": formatCreationTime(user.AdminPromoted),
+	}
+}
+
+func formatApiTokens(tokens []apiToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(tokens)
+	if err != nil {
+		panic(err)
+	}
+	return string(encoded)
+}
+
+func formatTotpCounter(counter int64) string {
+	if counter == 0 {
+		return ""
+	}
+	return strconv.FormatInt(counter, 10)
+}
+
+// Remove the given fields (keys of ToSecretData) from the user,
+// SetUser cannot be used for this since it keeps the value of empty fields
+func clearGamebaseUserFields(user GamebaseUser, fields []string) GamebaseUser {
+	data := map[string][]byte{}
+	for key, value := range user.ToSecretData() {
+		data[key] = []byte(value)
+	}
+	for _, field := range fields {
+		delete(data, field)
+	}
+	return NewGamebaseUserFromSecretData(user.Email, data)
+}
+
+func formatCreationTime(created time.Time) string {
+	if created.IsZero() {
+		return ""
+	}
+	return created.UTC().Format(time.RFC3339)
+}
+
+// construct the UserAccount returned by the admin endpoints
+func (user GamebaseUser) ToUserAccount() UserAccount {
+	return UserAccount{
+		Email:    user.Email,
+		FullName: user.Name,
+		Uuid:     user.Uuid,
+		Role:     string(user.Role.orDefault()),
+		Status:   string(user.Status.orDefault()),
 	}
 }
 
@@ -49,6 +192,16 @@ func encodeEmail(email string) string {
 	return string(buf)
 }
 
+// decode the email if the name has been created by encodeEmail
+func tryDecodeEmail(name string) (string, bool) {
+	encoding := kubernetesFriendlyEncoding()
+	decoded, err := encoding.DecodeString(name)
+	if err != nil {
+		return "", false
+	}
+	return string(decoded), true
+}
+
 // decodeEmail the email as base32 with kubernetes friendly padding ('0' instead of '=').
 func decodeEmail(email string) string {
 	src := []byte(email)
@@ -56,7 +209,7 @@ func decodeEmail(email string) string {
 	encoding := kubernetesFriendlyEncoding()
 	buf := make([]byte, encoding.DecodedLen(len(src)))
 	if i, err := encoding.Decode(buf, src); err != nil {
-		panic("Could not decode email address \"" + email + "\" beginning with byte " + string(i))
+		panic("Could not decode email address \"" + email + "\" beginning with byte " + fmt.Sprint(i))
 	} else {
 		return string(buf[:i])
 	}
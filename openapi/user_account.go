@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	uuidGen "github.com/twinj/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sync"
+	"time"
+)
+
+// Steps of an account deletion in the order they are executed
+const (
+	deletionStepDisableUser   = "disableUser"
+	deletionStepOrganizations = "leaveOrganizations"
+	deletionStepGameServers   = "deleteGameServers"
+	deletionStepNamespace     = "deleteNamespace"
+	deletionStepDeleteUser    = "deleteUser"
+)
+
+// Status of the whole account deletion or a single step
+const (
+	deletionPending   = "pending"
+	deletionRunning   = "running"
+	deletionCompleted = "completed"
+	deletionFailed    = "failed"
+)
+
+const (
+	namespaceDeletionTimeout = 10 * time.Minute
+	namespaceDeletionPoll    = 2 * time.Second
+	// the request is answered with the progress so far if the deletion takes longer
+	accountDeletionResponseTimeout = 10 * time.Second
+	// finished deletions can be queried for this long
+	accountDeletionRetention = time.Hour
+)
+
+// Progress of all account deletions started by this replica
+var accountDeletions = &accountDeletionTracker{deletions: map[string]*accountDeletion{}}
+
+type accountDeletionTracker struct {
+	mutex     sync.Mutex
+	deletions map[string]*accountDeletion
+}
+
+// A running or finished account deletion
+type accountDeletion struct {
+	mutex    sync.Mutex
+	state    AccountDeletion
+	finished time.Time
+	done     chan struct{}
+}
+
+// Start deleting the user together with the user namespace and all game servers in it.
+// The deletion continues in the background after the request has been answered,
+// use wait to block until it has finished or the request should be answered.
+func startAccountDeletion(cl kubernetesClient, users UserStore, email string) *accountDeletion {
+	deletion := &accountDeletion{
+		state: AccountDeletion{
+			Id:     uuidGen.NewV4().String(),
+			Status: deletionRunning,
+			Steps: []AccountDeletionStep{
+				{Name: deletionStepDisableUser, Status: deletionPending},
+				{Name: deletionStepOrganizations, Status: deletionPending},
+				{Name: deletionStepGameServers, Status: deletionPending},
+				{Name: deletionStepNamespace, Status: deletionPending},
+				{Name: deletionStepDeleteUser, Status: deletionPending},
+			},
+		},
+		done: make(chan struct{}),
+	}
+	accountDeletions.add(deletion)
+
+	go func() {
+		// the request context is cancelled as soon as the request has been answered
+		err := deleteUserAccount(context.Background(), cl, users, email, deletion)
+		deletion.finish(err)
+	}()
+	return deletion
+}
+
+func (t *accountDeletionTracker) add(deletion *accountDeletion) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	for id, existing := range t.deletions {
+		if finished := existing.finishedAt(); !finished.IsZero() && now.Sub(finished) > accountDeletionRetention {
+			delete(t.deletions, id)
+		}
+	}
+	t.deletions[deletion.state.Id] = deletion
+}
+
+// Lookup the progress of an account deletion
+func (t *accountDeletionTracker) get(id string) (*AccountDeletion, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	deletion, exists := t.deletions[id]
+	if !exists {
+		return nil, false
+	}
+	state := deletion.snapshot()
+	return &state, true
+}
+
+// Block until the deletion has finished or the timeout expired and report the current progress
+func (d *accountDeletion) wait(timeout time.Duration) AccountDeletion {
+	select {
+	case <-d.done:
+	case <-time.After(timeout):
+	}
+	return d.snapshot()
+}
+
+func (d *accountDeletion) snapshot() AccountDeletion {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state := d.state
+	state.Steps = append([]AccountDeletionStep{}, d.state.Steps...)
+	return state
+}
+
+func (d *accountDeletion) finishedAt() time.Time {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.finished
+}
+
+func (d *accountDeletion) update(step string, status string, details string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for i := range d.state.Steps {
+		if d.state.Steps[i].Name == step {
+			d.state.Steps[i].Status = status
+			d.state.Steps[i].Details = details
+		}
+	}
+}
+
+func (d *accountDeletion) finish(err error) {
+	d.mutex.Lock()
+	if err != nil {
+		d.state.Status = deletionFailed
+		d.state.Details = err.Error()
+	} else {
+		d.state.Status = deletionCompleted
+	}
+	d.finished = time.Now()
+	d.mutex.Unlock()
+	close(d.done)
+}
+
+// Run a single step of the deletion and record its outcome
+func (d *accountDeletion) run(step string, action func() (string, error)) error {
+	d.update(step, deletionRunning, "")
+	details, err := action()
+	if err != nil {
+		d.update(step, deletionFailed, err.Error())
+		return err
+	}
+	d.update(step, deletionCompleted, details)
+	return nil
+}
+
+// Remove the user together with the user namespace and all game servers in it.
+// The user record is deleted last so a failed deletion can simply be retried.
+func deleteUserAccount(ctx context.Context, cl kubernetesClient, users UserStore, email string, deletion *accountDeletion) error {
+	uuid, err := users.GetUuid(ctx, email)
+	if err != nil {
+		deletion.update(deletionStepDisableUser, deletionFailed, err.Error())
+		return err
+	}
+	namespace := defaultNamespaceUser + uuid
+
+	// log out all sessions and prevent new logins so no game servers are created meanwhile
+	err = deletion.run(deletionStepDisableUser, func() (string, error) {
+		if err := users.SetUser(ctx, email, GamebaseUser{Status: userDisabled}); err != nil {
+			return "", err
+		}
+		return "", revokeAllTokens(ctx, email)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = deletion.run(deletionStepOrganizations, func() (string, error) {
+		left, err := leaveOrganizations(ctx, cl, uuid)
+		return fmt.Sprintf("left %d organizations", left), err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = deletion.run(deletionStepGameServers, func() (string, error) {
+		gameServers, err := cl.GetGameServerList(ctx, namespace)
+		if err != nil {
+			return "", err
+		}
+		for _, gameServer := range gameServers {
+			if err := cl.DeleteGameserver(ctx, namespace, gameServer, false); err != nil && !apierrors.IsNotFound(err) {
+				return "", err
+			}
+		}
+		sharedGameServers, err := cl.GetSharedGameServerList(ctx, uuid)
+		if err != nil {
+			return "", err
+		}
+		for _, gameServer := range sharedGameServers {
+			if err := cl.SetGameServerMember(ctx, gameServer.deployment.Namespace, gameServer, uuid, ""); err != nil && !apierrors.IsNotFound(err) {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("deleted %d game servers, left %d shared game servers", len(gameServers), len(sharedGameServers)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = deletion.run(deletionStepNamespace, func() (string, error) {
+		if err := cl.DeleteNamespace(ctx, namespace); err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		deletion.update(deletionStepNamespace, deletionRunning, "waiting for namespace "+namespace+" to terminate")
+		return "", waitForNamespaceDeletion(ctx, cl, namespace)
+	})
+	if err != nil {
+		return err
+	}
+
+	return deletion.run(deletionStepDeleteUser, func() (string, error) {
+		return "", users.DeleteUser(ctx, email)
+	})
+}
+
+// Kubernetes removes the contents of a namespace asynchronously, poll until it is gone
+func waitForNamespaceDeletion(ctx context.Context, cl kubernetesClient, namespace string) error {
+	deadline := time.Now().Add(namespaceDeletionTimeout)
+	for {
+		exists, err := cl.NamespaceExists(ctx, namespace)
+		if err != nil || !exists {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return errors.New("namespace " + namespace + " is still terminating")
+		}
+		time.Sleep(namespaceDeletionPoll)
+	}
+}
+
+// Create the namespace of the user unless it already exists
+func ensureUserNamespace(ctx context.Context, cl kubernetesClient, uuid string) error {
+	_, err := cl.CreateNamespace(ctx, defaultNamespaceUser+uuid)
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// Send the link to verify the email address to a pending user
+func sendVerificationMail(ctx context.Context, user GamebaseUser) error {
+	token, err := createVerificationToken(user)
+	if err != nil {
+		return err
+	}
+	return mails.Send(ctx, verificationMail(user, token))
+}
@@ -0,0 +1,327 @@
+package openapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const invitationConfigMap = "gamebase-invitations"
+
+// Who is allowed to register
+type registrationMode string
+
+const (
+	registrationOpen   registrationMode = "open"
+	registrationInvite registrationMode = "invite"
+	registrationClosed registrationMode = "closed"
+)
+
+const (
+	invitationCodeLength        = 10
+	defaultInvitationUses       = 1
+	maxInvitationUses           = 1000
+	defaultInvitationExpiryDays = 7
+	maxInvitationExpiryDays     = 365
+	maxActiveInvitationsPerUser = 10
+)
+
+var (
+	errInvitationNotFound = errors.New("invitation does not exist")
+	errInvalidInvitation  = errors.New("invalid or expired invitation code")
+)
+
+// set up on creation of the httpRequestAuthenticator
+var (
+	registration   registrationMode
+	invitationRole userRole
+	invitations    invitationStore
+)
+
+// The registration mode is configured by REGISTRATION_MODE: open (default), invite or closed
+func newRegistrationMode() registrationMode {
+	switch mode := registrationMode(os.Getenv("REGISTRATION_MODE")); mode {
+	case "":
+		return registrationOpen
+	case registrationOpen, registrationInvite, registrationClosed:
+		return mode
+	default:
+		panic("Unknown REGISTRATION_MODE " + string(mode))
+	}
+}
+
+// The role required to create invitations is configured by INVITATION_ROLE (default user)
+func newInvitationRole() userRole {
+	role := userRole(os.Getenv("INVITATION_ROLE"))
+	if role == "" {
+		return roleUser
+	}
+	if !isValidUserRole(role) {
+		panic("Unknown INVITATION_ROLE " + string(role))
+	}
+	return role
+}
+
+// An invitation code which allows a limited number of registrations until it expires
+type invitation struct {
+	Code      string    `json:"code"`
+	CreatedBy string    `json:"createdBy"`
+	Created   time.Time `json:"created"`
+	Expires   time.Time `json:"expires"`
+	MaxUses   int       `json:"maxUses"`
+	Uses      int       `json:"uses"`
+}
+
+func (i invitation) isUsable(now time.Time) bool {
+	return i.Uses < i.MaxUses && now.Before(i.Expires)
+}
+
+// construct the Invitation returned by the invitation endpoints
+func (i invitation) toInvitation() Invitation {
+	return Invitation{
+		Code:      i.Code,
+		CreatedBy: i.CreatedBy,
+		Created:   i.Created,
+		Expires:   i.Expires,
+		MaxUses:   int32(i.MaxUses),
+		Uses:      int32(i.Uses),
+	}
+}
+
+// Create a new invitation with a random code
+func newInvitation(createdBy string, maxUses int, expiresIn time.Duration) (invitation, error) {
+	random := make([]byte, invitationCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return invitation{}, err
+	}
+	encoded := strings.ToLower(totpEncoding.EncodeToString(random))[:invitationCodeLength]
+	now := time.Now().UTC().Truncate(time.Second)
+	return invitation{
+		Code:      encoded[:invitationCodeLength/2] + "-" + encoded[invitationCodeLength/2:],
+		CreatedBy: createdBy,
+		Created:   now,
+		Expires:   now.Add(expiresIn),
+		MaxUses:   maxUses,
+	}, nil
+}
+
+func normalizeInvitationCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// Store for the invitation codes
+type invitationStore interface {
+	Create(ctx context.Context, entry invitation) error
+	// All invitations sorted by creation time, expired ones are removed
+	List(ctx context.Context) ([]invitation, error)
+	Delete(ctx context.Context, code string) error
+	// Use the invitation for a registration, fails with errInvalidInvitation if it is expired or used up
+	Consume(ctx context.Context, code string) error
+	// Give back a use of the invitation if the registration failed
+	Release(ctx context.Context, code string) error
+}
+
+// Select the invitation store based on the INVITATION_STORE environment variable
+func newInvitationStore(k kubernetesClient) invitationStore {
+	switch store := os.Getenv("INVITATION_STORE"); store {
+	case "", "kubernetes":
+		return &kubernetesInvitationStore{k: k}
+	case "memory":
+		return &memoryInvitationStore{invitations: map[string]invitation{}}
+	default:
+		panic("Unknown INVITATION_STORE " + store)
+	}
+}
+
+func sortInvitations(list []invitation) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+}
+
+// In-memory invitation store. Invitations are lost on restart and not shared between replicas.
+type memoryInvitationStore struct {
+	mutex       sync.Mutex
+	invitations map[string]invitation
+}
+
+func (s *memoryInvitationStore) Create(ctx context.Context, entry invitation) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.invitations[entry.Code] = entry
+	return nil
+}
+
+func (s *memoryInvitationStore) List(ctx context.Context) ([]invitation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	list := []invitation{}
+	for code, entry := range s.invitations {
+		if now.After(entry.Expires) {
+			delete(s.invitations, code)
+			continue
+		}
+		list = append(list, entry)
+	}
+	sortInvitations(list)
+	return list, nil
+}
+
+func (s *memoryInvitationStore) Delete(ctx context.Context, code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.invitations[code]; !exists {
+		return errInvitationNotFound
+	}
+	delete(s.invitations, code)
+	return nil
+}
+
+func (s *memoryInvitationStore) Consume(ctx context.Context, code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, exists := s.invitations[code]
+	if !exists || !entry.isUsable(time.Now()) {
+		return errInvalidInvitation
+	}
+	entry.Uses++
+	s.invitations[code] = entry
+	return nil
+}
+
+func (s *memoryInvitationStore) Release(ctx context.Context, code string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if entry, exists := s.invitations[code]; exists && entry.Uses > 0 {
+		entry.Uses--
+		s.invitations[code] = entry
+	}
+	return nil
+}
+
+// Invitation store persisted in a ConfigMap, the key is the code and the value the invitation as JSON.
+// Updates are guarded by the resource version so concurrent registrations cannot exceed the uses.
+type kubernetesInvitationStore struct {
+	k kubernetesClient
+}
+
+func (s *kubernetesInvitationStore) Create(ctx context.Context, entry invitation) error {
+	return s.update(ctx, func(data map[string]invitation) error {
+		data[entry.Code] = entry
+		return nil
+	})
+}
+
+func (s *kubernetesInvitationStore) List(ctx context.Context) ([]invitation, error) {
+	configMap, err := s.k.Client.CoreV1().ConfigMaps(defaultNamespace).Get(ctx, invitationConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []invitation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := []invitation{}
+	for _, entry := range decodeInvitations(configMap) {
+		if now.Before(entry.Expires) {
+			list = append(list, entry)
+		}
+	}
+	sortInvitations(list)
+	return list, nil
+}
+
+func (s *kubernetesInvitationStore) Delete(ctx context.Context, code string) error {
+	return s.update(ctx, func(data map[string]invitation) error {
+		if _, exists := data[code]; !exists {
+			return errInvitationNotFound
+		}
+		delete(data, code)
+		return nil
+	})
+}
+
+func (s *kubernetesInvitationStore) Consume(ctx context.Context, code string) error {
+	return s.update(ctx, func(data map[string]invitation) error {
+		entry, exists := data[code]
+		if !exists || !entry.isUsable(time.Now()) {
+			return errInvalidInvitation
+		}
+		entry.Uses++
+		data[code] = entry
+		return nil
+	})
+}
+
+func (s *kubernetesInvitationStore) Release(ctx context.Context, code string) error {
+	return s.update(ctx, func(data map[string]invitation) error {
+		if entry, exists := data[code]; exists && entry.Uses > 0 {
+			entry.Uses--
+			data[code] = entry
+		}
+		return nil
+	})
+}
+
+// Apply the change to the invitations in the ConfigMap and drop the expired ones
+func (s *kubernetesInvitationStore) update(ctx context.Context, change func(map[string]invitation) error) error {
+	configMaps := s.k.Client.CoreV1().ConfigMaps(defaultNamespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, invitationConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap, err = configMaps.Create(ctx, &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: invitationConfigMap},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+
+		data := decodeInvitations(configMap)
+		if err := change(data); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		configMap.Data = map[string]string{}
+		for code, entry := range data {
+			if now.After(entry.Expires) {
+				continue
+			}
+			encoded, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			configMap.Data[code] = string(encoded)
+		}
+
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func decodeInvitations(configMap *v1.ConfigMap) map[string]invitation {
+	data := map[string]invitation{}
+	for code, value := range configMap.Data {
+		var entry invitation
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			fmt.Println("Skipping invalid invitation " + code + ": " + err.Error())
+			continue
+		}
+		data[code] = entry
+	}
+	return data
+}
@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A plain text email sent to a user
+type mailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Delivers emails like the verification of new accounts to the users
+type mailer interface {
+	Send(ctx context.Context, message mailMessage) error
+}
+
+// set up on creation of the httpRequestAuthenticator
+var mails mailer
+
+// Select the mailer based on the MAILER environment variable
+func newMailer() mailer {
+	switch kind := os.Getenv("MAILER"); kind {
+	case "", "log":
+		return logMailer{}
+	case "file":
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mails.txt"
+		}
+		return &fileMailer{path: path}
+	case "smtp":
+		return newSmtpMailer()
+	default:
+		panic("Unknown MAILER " + kind)
+	}
+}
+
+// The URL under which the backend is reachable by the users, used for links in emails
+func publicUrl() string {
+	if url := os.Getenv("PUBLIC_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:80"
+}
+
+func (message mailMessage) format(from string) string {
+	headers := []string{
+		"From: " + from,
+		"To: " + message.To,
+		"Subject: " + message.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	return strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(message.Body, "\n", "\r\n")
+}
+
+// Prints the emails to stdout, useful for local development
+type logMailer struct{}
+
+func (m logMailer) Send(ctx context.Context, message mailMessage) error {
+	fmt.Println("Mail to " + message.To + ": " + message.Subject)
+	fmt.Println(message.Body)
+	return nil
+}
+
+// Appends the emails to a file, useful for local testing
+type fileMailer struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func (m *fileMailer) Send(ctx context.Context, message mailMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(message.format("gamebase@localhost") + "\r\n\r\n")
+	return err
+}
+
+// Sends the emails through an SMTP server
+type smtpMailer struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+func newSmtpMailer() *smtpMailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		panic("SMTP_HOST is required for the smtp mailer")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		panic("SMTP_FROM is required for the smtp mailer")
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return &smtpMailer{address: host + ":" + port, auth: auth, from: from}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message mailMessage) error {
+	return smtp.SendMail(m.address, m.auth, m.from, []string{message.To}, []byte(message.format(m.from)))
+}
+
+// The URL of the frontend, used for links to pages of the frontend
+func frontendUrl() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return publicUrl()
+}
+
+func verificationMail(user GamebaseUser, token string) mailMessage {
+	return mailMessage{
+		To:      user.Email,
+		Subject: "Verify your GameBase account",
+		Body: "Hello " + user.Name + ",\n\n" +
+			"please confirm your email address by opening the following link:\n\n" +
+			publicUrl() + "/auth/verify?token=" + token + "\n\n" +
+			fmt.Sprintf("The link is valid for %d hours.\n", int(verificationTokenDuration.Hours())) +
+			"If you did not register at GameBase you can ignore this email.\n",
+	}
+}
+
+func emailChangeMail(user GamebaseUser, newEmail string, token string) mailMessage {
+	return mailMessage{
+		To:      newEmail,
+		Subject: "Confirm your new GameBase email address",
+		Body: "Hello " + user.Name + ",\n\n" +
+			"please confirm that you want to use this email address for your GameBase account by opening the following link:\n\n" +
+			publicUrl() + "/auth/verify?token=" + token + "\n\n" +
+			fmt.Sprintf("The link is valid for %d hours and can only be used once.\n", int(verificationTokenDuration.Hours())) +
+			"If you did not change your email address at GameBase you can ignore this email.\n",
+	}
+}
+
+func resetMail(user GamebaseUser, token string) mailMessage {
+	return mailMessage{
+		To:      user.Email,
+		Subject: "Reset your GameBase password",
+		Body: "Hello " + user.Name + ",\n\n" +
+			"you can choose a new password by opening the following link:\n\n" +
+			frontendUrl() + "/reset-password?token=" + token + "\n\n" +
+			fmt.Sprintf("The link is valid for %d minutes and can only be used once.\n", int(resetTokenDuration.Minutes())) +
+			"If you did not request a new password you can ignore this email.\n",
+	}
+}
@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// The role of a user determines which endpoints may be used.
+// Every role includes the permissions of the roles below it.
+type userRole string
+
+const (
+	roleUser     userRole = "user"
+	roleOperator userRole = "operator"
+	roleAdmin    userRole = "admin"
+)
+
+func (role userRole) level() int {
+	switch role {
+	case roleAdmin:
+		return 2
+	case roleOperator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// users created before roles were introduced don't have a role
+func (role userRole) orDefault() userRole {
+	if role == "" {
+		return roleUser
+	}
+	return role
+}
+
+// Check if the role grants the permissions of the required role
+func (role userRole) includes(required userRole) bool {
+	return role.level() >= required.level()
+}
+
+func isValidUserRole(role userRole) bool {
+	return role == roleUser || role == roleOperator || role == roleAdmin
+}
+
+// Check if the email address is listed in the comma separated ADMIN_EMAILS environment variable
+func isConfiguredAdmin(email string) bool {
+	for _, admin := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant the admin role to a user listed in ADMIN_EMAILS. Must only be called once the user has
+// proven to own the email address, i.e. when it is verified or on startup for active users.
+// The promotion is recorded and happens only once, so admins who have been demoted stay demoted.
+func promoteConfiguredAdmin(ctx context.Context, users UserStore, user *GamebaseUser) error {
+	if !user.AdminPromoted.IsZero() || !isConfiguredAdmin(user.Email) {
+		return nil
+	}
+	now := time.Now().UTC()
+	if err := users.SetUser(ctx, user.Email, GamebaseUser{Role: roleAdmin, AdminPromoted: now}); err != nil {
+		return err
+	}
+	fmt.Println("Granted admin role to " + user.Email + " listed in ADMIN_EMAILS")
+	user.Role = roleAdmin
+	user.AdminPromoted = now
+	return nil
+}
+
+// Promote the active users listed in ADMIN_EMAILS which verified their address before they were listed
+func promoteConfiguredAdmins(ctx context.Context, users UserStore) error {
+	if os.Getenv("ADMIN_EMAILS") == "" {
+		return nil
+	}
+	list, err := users.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		if list[i].Status.orDefault() != userActive {
+			continue
+		}
+		if err := promoteConfiguredAdmin(ctx, users, &list[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package openapi
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	uuidGen "github.com/twinj/uuid"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errUserNotFound = errors.New("user does not exist")
+	errEmailTaken   = errors.New("email address is already taken")
+)
+
+// UserStore persists the GamebaseUser records identified by their email address
+type UserStore interface {
+	// Lookup the user, fails with errUserNotFound if there is none
+	GetUser(ctx context.Context, email string) (*GamebaseUser, error)
+	// Create the user or update the existing one. Empty fields keep their current value
+	// and newly created users are assigned a uuid.
+	SetUser(ctx context.Context, email string, user GamebaseUser) error
+	// Atomically apply the update to the current user, concurrent changes are not lost.
+	// Fields which are empty after the update are removed, the update must not change email and uuid.
+	// Fails with errUserNotFound if there is no user and with the error of the update if it fails.
+	UpdateUser(ctx context.Context, email string, update func(user *GamebaseUser) error) error
+	DeleteUser(ctx context.Context, email string) error
+	// Lookup the uuid of the user which is used to name the user namespace
+	GetUuid(ctx context.Context, email string) (string, error)
+	// Lookup the user by the uuid, which is kept when the email address changes.
+	// Fails with errUserNotFound if there is none.
+	GetUserByUuid(ctx context.Context, uuid string) (*GamebaseUser, error)
+	// List all users ordered by email
+	ListUsers(ctx context.Context) ([]GamebaseUser, error)
+	// Atomically move the user with all fields including the uuid to a new email address.
+	// Fails with errEmailTaken if there already is a user with the new address.
+	RenameUser(ctx context.Context, email string, newEmail string) error
+	// Remove the given fields (keys of GamebaseUser.ToSecretData) from the user
+	ClearUserFields(ctx context.Context, email string, fields ...string) error
+}
+
+// Email addresses are case insensitive. Every email taken from a request is normalized
+// before it is used to lookup or store a user.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Select the user store based on the USER_STORE environment variable
+func newUserStore(k kubernetesClient) UserStore {
+	switch store := os.Getenv("USER_STORE"); store {
+	case "", "kubernetes":
+		return kubernetesUserStore{k: k}
+	case "memory":
+		return newMemoryUserStore()
+	case "sqlite":
+		path := os.Getenv("USER_STORE_SQLITE_PATH")
+		if path == "" {
+			path = "gamebase.db"
+		}
+		sqlStore, err := newSqlUserStore("sqlite3", path)
+		if err != nil {
+			panic("Could not open user database: " + err.Error())
+		}
+		return sqlStore
+	default:
+		panic("Unknown USER_STORE " + store)
+	}
+}
+
+// Generate the uuid of a new user
+func newUserUuid(email string) string {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+	id := uuidGen.NewV5(uuidGen.NameSpaceURL, "game-base.de/backend/user", email, time.Now().UTC(), random)
+	return uuidGen.Formatter(id, uuidGen.FormatHex)
+}
+
+// Stores every user in a kubernetes secret named after the encoded email address
+type kubernetesUserStore struct {
+	k kubernetesClient
+}
+
+func (s kubernetesUserStore) GetUser(ctx context.Context, email string) (*GamebaseUser, error) {
+	user, err := s.k.GetUserSecret(ctx, email)
+	if apierrors.IsNotFound(err) {
+		return nil, errUserNotFound
+	}
+	return user, err
+}
+
+func (s kubernetesUserStore) SetUser(ctx context.Context, email string, user GamebaseUser) error {
+	return s.k.SetUserSecret(ctx, email, user)
+}
+
+func (s kubernetesUserStore) UpdateUser(ctx context.Context, email string, update func(user *GamebaseUser) error) error {
+	err := s.k.UpdateUserSecret(ctx, email, update)
+	if apierrors.IsNotFound(err) {
+		return errUserNotFound
+	}
+	return err
+}
+
+func (s kubernetesUserStore) DeleteUser(ctx context.Context, email string) error {
+	err := s.k.DeleteUserSecret(ctx, email)
+	if apierrors.IsNotFound(err) {
+		return errUserNotFound
+	}
+	return err
+}
+
+func (s kubernetesUserStore) GetUuid(ctx context.Context, email string) (string, error) {
+	uuid, err := s.k.GetUuid(ctx, email)
+	if apierrors.IsNotFound(err) {
+		return "", errUserNotFound
+	}
+	return uuid, err
+}
+
+func (s kubernetesUserStore) GetUserByUuid(ctx context.Context, uuid string) (*GamebaseUser, error) {
+	user, err := s.k.GetUserSecretByUuid(ctx, uuid)
+	if apierrors.IsNotFound(err) {
+		return nil, errUserNotFound
+	}
+	return user, err
+}
+
+func (s kubernetesUserStore) RenameUser(ctx context.Context, email string, newEmail string) error {
+	err := s.k.RenameUserSecret(ctx, email, newEmail)
+	if apierrors.IsNotFound(err) {
+		return errUserNotFound
+	}
+	if apierrors.IsAlreadyExists(err) {
+		return errEmailTaken
+	}
+	return err
+}
+
+func (s kubernetesUserStore) ClearUserFields(ctx context.Context, email string, fields ...string) error {
+	err := s.k.ClearUserSecretFields(ctx, email, fields)
+	if apierrors.IsNotFound(err) {
+		return errUserNotFound
+	}
+	return err
+}
+
+func (s kubernetesUserStore) ListUsers(ctx context.Context) ([]GamebaseUser, error) {
+	users, err := s.k.ListUserSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sortUsers(users)
+	return users, nil
+}
+
+// Keeps the users in memory, mostly useful for tests and local development
+type memoryUserStore struct {
+	mutex sync.RWMutex
+	users map[string]GamebaseUser
+}
+
+func newMemoryUserStore() *memoryUserStore {
+	return &memoryUserStore{
+		users: map[string]GamebaseUser{},
+	}
+}
+
+func (s *memoryUserStore) GetUser(ctx context.Context, email string) (*GamebaseUser, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	user, exists := s.users[email]
+	if !exists {
+		return nil, errUserNotFound
+	}
+	return &user, nil
+}
+
+func (s *memoryUserStore) SetUser(ctx context.Context, email string, user GamebaseUser) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	existing, exists := s.users[email]
+	if !exists {
+		existing = GamebaseUser{Email: email, Uuid: newUserUuid(email), Created: time.Now()}
+	}
+	s.users[email] = mergeGamebaseUser(existing, user)
+	return nil
+}
+
+func (s *memoryUserStore) UpdateUser(ctx context.Context, email string, update func(user *GamebaseUser) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	user, exists := s.users[email]
+	if !exists {
+		return errUserNotFound
+	}
+	if err := update(&user); err != nil {
+		return err
+	}
+	s.users[email] = user
+	return nil
+}
+
+func (s *memoryUserStore) DeleteUser(ctx context.Context, email string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.users[email]; !exists {
+		return errUserNotFound
+	}
+	delete(s.users, email)
+	return nil
+}
+
+func (s *memoryUserStore) GetUuid(ctx context.Context, email string) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	user, exists := s.users[email]
+	if !exists {
+		return "", errUserNotFound
+	}
+	return user.Uuid, nil
+}
+
+func (s *memoryUserStore) GetUserByUuid(ctx context.Context, uuid string) (*GamebaseUser, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, user := range s.users {
+		if user.Uuid == uuid {
+			return &user, nil
+		}
+	}
+	return nil, errUserNotFound
+}
+
+func (s *memoryUserStore) ListUsers(ctx context.Context) ([]GamebaseUser, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	users := make([]GamebaseUser, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sortUsers(users)
+	return users, nil
+}
+
+func (s *memoryUserStore) RenameUser(ctx context.Context, email string, newEmail string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	user, exists := s.users[email]
+	if !exists {
+		return errUserNotFound
+	}
+	if _, taken := s.users[newEmail]; taken {
+		return errEmailTaken
+	}
+	user.Email = newEmail
+	s.users[newEmail] = user
+	delete(s.users, email)
+	return nil
+}
+
+func (s *memoryUserStore) ClearUserFields(ctx context.Context, email string, fields ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	user, exists := s.users[email]
+	if !exists {
+		return errUserNotFound
+	}
+	s.users[email] = clearGamebaseUserFields(user, fields)
+	return nil
+}
+
+func sortUsers(users []GamebaseUser) {
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+}
+
+// Overwrite the fields of the existing user with the non-empty fields of the update
+func mergeGamebaseUser(existing GamebaseUser, update GamebaseUser) GamebaseUser {
+	data := existing.ToSecretData()
+	for key, value := range update.ToSecretData() {
+		if value != "" {
+			data[key] = value
+		}
+	}
+
+	secretData := map[string][]byte{}
+	for key, value := range data {
+		secretData[key] = []byte(value)
+	}
+	return NewGamebaseUserFromSecretData(existing.Email, secretData)
+}
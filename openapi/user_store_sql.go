@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Stores the users in a SQL database. The fields of the user are kept as JSON
+// in the same format as the data of the kubernetes user secrets.
+// The database driver has to be linked into the binary, see user_store_sqlite.go
+type sqlUserStore struct {
+	db *sql.DB
+}
+
+func newSqlUserStore(driver string, dataSource string) (*sqlUserStore, error) {
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (
+		email TEXT PRIMARY KEY,
+		uuid TEXT NOT NULL UNIQUE,
+		data TEXT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqlUserStore{db: db}, nil
+}
+
+func (s *sqlUserStore) GetUser(ctx context.Context, email string) (*GamebaseUser, error) {
+	return getSqlUser(ctx, s.db, email)
+}
+
+func (s *sqlUserStore) SetUser(ctx context.Context, email string, user GamebaseUser) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := getSqlUser(ctx, tx, email)
+	if err == errUserNotFound {
+		created := mergeGamebaseUser(GamebaseUser{Email: email, Uuid: newUserUuid(email), Created: time.Now()}, user)
+		data, err := marshalSqlUser(created)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO users (email, uuid, data) VALUES (?, ?, ?)", email, created.Uuid, data)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+	if err != nil {
+		return err
+	}
+
+	updated := mergeGamebaseUser(*existing, user)
+	data, err := marshalSqlUser(updated)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET uuid = ?, data = ? WHERE email = ?", updated.Uuid, data, email); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlUserStore) UpdateUser(ctx context.Context, email string, update func(user *GamebaseUser) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := getSqlUser(ctx, tx, email)
+	if err != nil {
+		return err
+	}
+	if err := update(existing); err != nil {
+		return err
+	}
+	data, err := marshalSqlUser(*existing)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET data = ? WHERE email = ?", data, email); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlUserStore) ClearUserFields(ctx context.Context, email string, fields ...string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := getSqlUser(ctx, tx, email)
+	if err != nil {
+		return err
+	}
+	data, err := marshalSqlUser(clearGamebaseUserFields(*existing, fields))
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET data = ? WHERE email = ?", data, email); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlUserStore) DeleteUser(ctx context.Context, email string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE email = ?", email)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return errUserNotFound
+	}
+	return err
+}
+
+func (s *sqlUserStore) GetUuid(ctx context.Context, email string) (string, error) {
+	var uuid string
+	err := s.db.QueryRowContext(ctx, "SELECT uuid FROM users WHERE email = ?", email).Scan(&uuid)
+	if err == sql.ErrNoRows {
+		return "", errUserNotFound
+	}
+	return uuid, err
+}
+
+func (s *sqlUserStore) GetUserByUuid(ctx context.Context, uuid string) (*GamebaseUser, error) {
+	var email, data string
+	err := s.db.QueryRowContext(ctx, "SELECT email, data FROM users WHERE uuid = ?", uuid).Scan(&email, &data)
+	if err == sql.ErrNoRows {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSqlUser(email, data)
+}
+
+func (s *sqlUserStore) ListUsers(ctx context.Context) ([]GamebaseUser, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT email, data FROM users ORDER BY email")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []GamebaseUser{}
+	for rows.Next() {
+		var email, data string
+		if err := rows.Scan(&email, &data); err != nil {
+			return nil, err
+		}
+		user, err := unmarshalSqlUser(email, data)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func (s *sqlUserStore) RenameUser(ctx context.Context, email string, newEmail string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := getSqlUser(ctx, tx, email)
+	if err != nil {
+		return err
+	}
+	existing.Email = newEmail
+	data, err := marshalSqlUser(*existing)
+	if err != nil {
+		return err
+	}
+	// the primary key decides whether the new address is taken, a separate check would race with concurrent renames
+	_, err = tx.ExecContext(ctx, "UPDATE users SET email = ?, data = ? WHERE email = ?", newEmail, data, email)
+	if err != nil && isSqlUniqueViolation(err) {
+		return errEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reports whether err is a violation of a PRIMARY KEY or UNIQUE constraint.
+// Replaced by the file linking the database driver, which knows the error type of the driver.
+var isSqlUniqueViolation = func(err error) bool {
+	return false
+}
+
+// common interface of sql.DB and sql.Tx
+type sqlQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getSqlUser(ctx context.Context, db sqlQueryer, email string) (*GamebaseUser, error) {
+	var data string
+	err := db.QueryRowContext(ctx, "SELECT data FROM users WHERE email = ?", email).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSqlUser(email, data)
+}
+
+func unmarshalSqlUser(email string, data string) (*GamebaseUser, error) {
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, err
+	}
+	secretData := map[string][]byte{}
+	for key, value := range fields {
+		secretData[key] = []byte(value)
+	}
+	user := NewGamebaseUserFromSecretData(email, secretData)
+	return &user, nil
+}
+
+func marshalSqlUser(user GamebaseUser) (string, error) {
+	data, err := json.Marshal(user.ToSecretData())
+	return string(data), err
+}
@@ -0,0 +1,22 @@
+//go:build sqlite
+// +build sqlite
+
+package openapi
+
+// The SQLite driver requires cgo and is therefore only linked into the binary
+// when building with -tags sqlite (USER_STORE=sqlite)
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	isSqlUniqueViolation = func(err error) bool {
+		var sqliteErr sqlite3.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+}
@@ -0,0 +1,20 @@
+//go:build sqlite
+// +build sqlite
+
+package openapi
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSqliteUserStore(t *testing.T) {
+	testUserStore(t, func(t *testing.T) UserStore {
+		store, err := newSqlUserStore("sqlite3", filepath.Join(t.TempDir(), "users.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.db.Close() })
+		return store
+	})
+}
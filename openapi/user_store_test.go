@@ -0,0 +1,226 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Behaviour every UserStore backend has to implement
+func testUserStore(t *testing.T, newStore func(t *testing.T) UserStore) {
+	ctx := context.Background()
+
+	t.Run("missing user", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.GetUser(ctx, "missing@example.com"); err != errUserNotFound {
+			t.Errorf("GetUser() error = %v, want %v", err, errUserNotFound)
+		}
+		if _, err := store.GetUuid(ctx, "missing@example.com"); err != errUserNotFound {
+			t.Errorf("GetUuid() error = %v, want %v", err, errUserNotFound)
+		}
+		if err := store.DeleteUser(ctx, "missing@example.com"); err != errUserNotFound {
+			t.Errorf("DeleteUser() error = %v, want %v", err, errUserNotFound)
+		}
+		if _, err := store.GetUserByUuid(ctx, "missing"); err != errUserNotFound {
+			t.Errorf("GetUserByUuid() error = %v, want %v", err, errUserNotFound)
+		}
+		update := func(user *GamebaseUser) error { return nil }
+		if err := store.UpdateUser(ctx, "missing@example.com", update); err != errUserNotFound {
+			t.Errorf("UpdateUser() error = %v, want %v", err, errUserNotFound)
+		}
+	})
+
+	t.Run("create and update", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SetUser(ctx, "user@example.com", GamebaseUser{Name: "user", Password: "hash", Role: roleUser}); err != nil {
+			t.Fatal(err)
+		}
+		created, err := store.GetUser(ctx, "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.Uuid == "" || created.Created.IsZero() || created.Email != "user@example.com" {
+			t.Errorf("created user %+v lacks uuid, creation time or email", created)
+		}
+
+		// empty fields keep their value
+		if err := store.SetUser(ctx, "user@example.com", GamebaseUser{Name: "renamed"}); err != nil {
+			t.Fatal(err)
+		}
+		updated, err := store.GetUser(ctx, "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Name != "renamed" || updated.Password != "hash" || updated.Role != roleUser || updated.Uuid != created.Uuid {
+			t.Errorf("updated user = %+v", updated)
+		}
+		if uuid, err := store.GetUuid(ctx, "user@example.com"); err != nil || uuid != created.Uuid {
+			t.Errorf("GetUuid() = %s, %v, want %s", uuid, err, created.Uuid)
+		}
+	})
+
+	t.Run("uuids are unique", func(t *testing.T) {
+		store := newStore(t)
+		for _, email := range []string{"first@example.com", "second@example.com"} {
+			if err := store.SetUser(ctx, email, GamebaseUser{Name: email}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		first, _ := store.GetUuid(ctx, "first@example.com")
+		second, _ := store.GetUuid(ctx, "second@example.com")
+		if first == second {
+			t.Errorf("both users have the uuid %s", first)
+		}
+	})
+
+	t.Run("list ordered by email", func(t *testing.T) {
+		store := newStore(t)
+		for _, email := range []string{"b@example.com", "c@example.com", "a@example.com"} {
+			if err := store.SetUser(ctx, email, GamebaseUser{Name: email}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		users, err := store.ListUsers(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emails := []string{}
+		for _, user := range users {
+			emails = append(emails, user.Email)
+		}
+		if len(emails) != 3 || emails[0] != "a@example.com" || emails[1] != "b@example.com" || emails[2] != "c@example.com" {
+			t.Errorf("ListUsers() = %v", emails)
+		}
+	})
+
+	t.Run("clear fields", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SetUser(ctx, "user@example.com", GamebaseUser{Name: "user", TotpSecret: "secret", TotpPending: "pending"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.ClearUserFields(ctx, "user@example.com", "totp_pending"); err != nil {
+			t.Fatal(err)
+		}
+		user, err := store.GetUser(ctx, "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.TotpPending != "" || user.TotpSecret != "secret" || user.Name != "user" {
+			t.Errorf("user after clearing = %+v", user)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SetUser(ctx, "user@example.com", GamebaseUser{Name: "user"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteUser(ctx, "user@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetUser(ctx, "user@example.com"); err != errUserNotFound {
+			t.Errorf("GetUser() error = %v, want %v", err, errUserNotFound)
+		}
+	})
+
+	t.Run("atomic update", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SetUser(ctx, "user@example.com", GamebaseUser{Name: "user", TotpPending: "pending"}); err != nil {
+			t.Fatal(err)
+		}
+		err := store.UpdateUser(ctx, "user@example.com", func(user *GamebaseUser) error {
+			user.Name = "updated"
+			user.TotpPending = ""
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a failing update is not stored
+		errFailed := errors.New("failed")
+		err = store.UpdateUser(ctx, "user@example.com", func(user *GamebaseUser) error {
+			user.Name = "failed"
+			return errFailed
+		})
+		if err != errFailed {
+			t.Errorf("UpdateUser() error = %v, want %v", err, errFailed)
+		}
+		user, err := store.GetUser(ctx, "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != "updated" || user.TotpPending != "" || user.Uuid == "" {
+			t.Errorf("user after update = %+v", user)
+		}
+	})
+
+	t.Run("lookup by uuid", func(t *testing.T) {
+		store := newStore(t)
+		if err := store.SetUser(ctx, "old@example.com", GamebaseUser{Name: "user"}); err != nil {
+			t.Fatal(err)
+		}
+		uuid, err := store.GetUuid(ctx, "old@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RenameUser(ctx, "old@example.com", "new@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		user, err := store.GetUserByUuid(ctx, uuid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Email != "new@example.com" || user.Name != "user" {
+			t.Errorf("GetUserByUuid() = %+v, want the renamed user", user)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		email    string
+		newEmail string
+		wantErr  error
+	}{
+		{"rename", "old@example.com", "new@example.com", nil},
+		{"rename to a taken address", "old@example.com", "taken@example.com", errEmailTaken},
+		{"rename a missing user", "missing@example.com", "new@example.com", errUserNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newStore(t)
+			for _, email := range []string{"old@example.com", "taken@example.com"} {
+				if err := store.SetUser(ctx, email, GamebaseUser{Name: email, Password: "hash"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			old, _ := store.GetUser(ctx, "old@example.com")
+
+			if err := store.RenameUser(ctx, test.email, test.newEmail); err != test.wantErr {
+				t.Fatalf("RenameUser() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				// nothing has been changed
+				if taken, err := store.GetUser(ctx, "taken@example.com"); err != nil || taken.Name != "taken@example.com" {
+					t.Errorf("taken user = %+v, %v", taken, err)
+				}
+				return
+			}
+			if _, err := store.GetUser(ctx, test.email); err != errUserNotFound {
+				t.Errorf("old address still exists: %v", err)
+			}
+			renamed, err := store.GetUser(ctx, test.newEmail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if renamed.Email != test.newEmail || renamed.Uuid != old.Uuid || renamed.Name != old.Name || renamed.Password != old.Password {
+				t.Errorf("renamed user = %+v, want the fields of %+v", renamed, old)
+			}
+		})
+	}
+}
+
+func TestMemoryUserStore(t *testing.T) {
+	testUserStore(t, func(t *testing.T) UserStore {
+		return newMemoryUserStore()
+	})
+}